@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/ashtishad/xm/common"
+	"github.com/ashtishad/xm/infra/postgres"
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/ashtishad/xm/internal/purge"
+)
+
+// runCommand executes a one-shot admin command instead of starting the server.
+// It reports false when args don't name a known command.
+func runCommand(ctx context.Context, args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+	case "purge-companies":
+		return true, purgeCompanies(ctx, args[1:])
+	case "promote-user":
+		return true, promoteUser(ctx, args[1:])
+	default:
+		return false, nil
+	}
+}
+
+// purgeCompanies permanently removes companies soft-deleted longer than the retention period,
+// the same operation the purge worker runs on schedule.
+// Usage: xm purge-companies [-retention-days N]; the retention defaults to PURGE_RETENTION_DAYS.
+func purgeCompanies(ctx context.Context, args []string) error {
+	logger := common.NewSlogger()
+
+	cfg, err := common.LoadConfig(logger)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	retentionDays, err := parsePurgeRetention(args, cfg.Purge.Retention)
+	if err != nil {
+		return err
+	}
+
+	if err := domain.SetEventSchemaBase(cfg.Events.SchemaBaseURI); err != nil {
+		return err
+	}
+
+	db, err := postgres.NewConnection(ctx, logger, cfg.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create database connection pool: %w", err)
+	}
+	defer db.Close()
+
+	companyRepo := domain.NewCompanyRepository(db, logger, domain.NewEventRepository(db, logger))
+	purger := purge.NewCompanyPurger(companyRepo, purge.PurgerConfig{
+		Retention: time.Duration(retentionDays) * 24 * time.Hour,
+		BatchSize: cfg.Purge.BatchSize,
+	}, logger)
+
+	purged, err := purger.RunOnce(ctx)
+	if err != nil {
+		return fmt.Errorf("purged %d companies before failing: %w", purged, err)
+	}
+
+	logger.Info("company purge finished", "purged", purged, "retentionDays", retentionDays)
+	return nil
+}
+
+// parsePurgeRetention returns the retention in days of a purge-companies run, from the -retention-days
+// flag or else the configured retention. A zero configured retention means purging is disabled, so
+// falling back to it is refused: purging everything deleted so far takes an explicit -retention-days 0.
+func parsePurgeRetention(args []string, configured time.Duration) (int, error) {
+	fs := flag.NewFlagSet("purge-companies", flag.ContinueOnError)
+	retentionDays := fs.Int("retention-days", int(configured/(24*time.Hour)), "purge companies deleted more than this many days ago")
+	if err := fs.Parse(args); err != nil {
+		return 0, err
+	}
+
+	explicit := false
+	fs.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "retention-days" })
+
+	if !explicit && configured <= 0 {
+		return 0, errors.New("purging is disabled by PURGE_RETENTION_DAYS=0, pass -retention-days to purge anyway")
+	}
+
+	if *retentionDays < 0 {
+		return 0, errors.New("retention-days must not be negative")
+	}
+
+	return *retentionDays, nil
+}
+
+// promoteUser sets the role of the user with the given email, which is how the first admin is created.
+// Usage: xm promote-user -email EMAIL [-role admin|editor|viewer]; the role defaults to admin.
+func promoteUser(ctx context.Context, args []string) error {
+	email, role, err := parsePromoteUserArgs(args)
+	if err != nil {
+		return err
+	}
+
+	logger := common.NewSlogger()
+
+	cfg, err := common.LoadConfig(logger)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	db, err := postgres.NewConnection(ctx, logger, cfg.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create database connection pool: %w", err)
+	}
+	defer db.Close()
+
+	userRepo := domain.NewUserRepository(db, logger)
+
+	user, appErr := userRepo.FindBy(ctx, common.DBColumnEmail, email)
+	if appErr != nil {
+		return fmt.Errorf("failed to find user %s: %w", email, appErr)
+	}
+
+	if _, appErr := userRepo.UpdateRole(ctx, user.UUID, role); appErr != nil {
+		return fmt.Errorf("failed to update role of user %s: %w", email, appErr)
+	}
+
+	logger.Info("user role updated", "email", email, "previousRole", user.Role, "role", role)
+	return nil
+}
+
+// parsePromoteUserArgs returns the email and role of a promote-user run.
+func parsePromoteUserArgs(args []string) (string, domain.UserRole, error) {
+	fs := flag.NewFlagSet("promote-user", flag.ContinueOnError)
+	email := fs.String("email", "", "email of the user to promote")
+	role := fs.String("role", string(domain.UserRoleAdmin), "role to assign: admin, editor or viewer")
+	if err := fs.Parse(args); err != nil {
+		return "", "", err
+	}
+
+	if *email == "" {
+		return "", "", errors.New("email is required")
+	}
+
+	switch domain.UserRole(*role) {
+	case domain.UserRoleAdmin, domain.UserRoleEditor, domain.UserRoleViewer:
+		return *email, domain.UserRole(*role), nil
+	default:
+		return "", "", fmt.Errorf("unknown role %q, want admin, editor or viewer", *role)
+	}
+}
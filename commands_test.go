@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xm/internal/domain"
+)
+
+func TestParsePurgeRetention(t *testing.T) {
+	thirtyDays := 30 * 24 * time.Hour
+
+	tests := []struct {
+		name       string
+		args       []string
+		configured time.Duration
+		want       int
+		wantErr    bool
+	}{
+		{"Configured retention", nil, thirtyDays, 30, false},
+		{"Flag overrides configured retention", []string{"-retention-days", "90"}, thirtyDays, 90, false},
+		{"Purging disabled", nil, 0, 0, true},
+		{"Purging disabled, explicit retention", []string{"-retention-days", "7"}, 0, 7, false},
+		{"Purging disabled, explicit zero retention", []string{"-retention-days", "0"}, 0, 0, false},
+		{"Negative retention", []string{"-retention-days", "-1"}, thirtyDays, 0, true},
+		{"Unknown flag", []string{"-force"}, thirtyDays, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePurgeRetention(tt.args, tt.configured)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parsePurgeRetention() error = nil, expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parsePurgeRetention() unexpected error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("parsePurgeRetention() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePromoteUserArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantEmail string
+		wantRole  domain.UserRole
+		wantErr   bool
+	}{
+		{"Default role", []string{"-email", "john@example.com"}, "john@example.com", domain.UserRoleAdmin, false},
+		{"Explicit role", []string{"-email", "john@example.com", "-role", "editor"}, "john@example.com", domain.UserRoleEditor, false},
+		{"Missing email", []string{"-role", "admin"}, "", "", true},
+		{"Unknown role", []string{"-email", "john@example.com", "-role", "owner"}, "", "", true},
+		{"Unknown flag", []string{"-email", "john@example.com", "-force"}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email, role, err := parsePromoteUserArgs(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parsePromoteUserArgs() error = nil, expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parsePromoteUserArgs() unexpected error = %v", err)
+			}
+
+			if email != tt.wantEmail || role != tt.wantRole {
+				t.Errorf("parsePromoteUserArgs() got = %v, %v, want %v, %v", email, role, tt.wantEmail, tt.wantRole)
+			}
+		})
+	}
+}
@@ -135,3 +135,28 @@ func NewUnauthorizedError(message string) AppError {
 func NewConflictError(message string) AppError {
 	return newAppError(http.StatusConflict, message)
 }
+
+// NewPreconditionFailedError creates a new AppError for failed request preconditions, such as
+// an If-Match header that no longer matches the current version of a resource.
+//
+// Example:
+//
+//	err := NewPreconditionFailedError("Company has been modified")
+//	response := ErrorResponse(err)
+//	w.WriteHeader(err.Code())
+//	json.NewEncoder(w).Encode(response)
+func NewPreconditionFailedError(message string) AppError {
+	return newAppError(http.StatusPreconditionFailed, message)
+}
+
+// NewForbiddenError creates a new AppError for authenticated requests that are not allowed.
+//
+// Example:
+//
+//	err := NewForbiddenError("Not allowed to modify this company")
+//	response := ErrorResponse(err)
+//	w.WriteHeader(err.Code())
+//	json.NewEncoder(w).Encode(response)
+func NewForbiddenError(message string) AppError {
+	return newAppError(http.StatusForbidden, message)
+}
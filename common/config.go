@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/ashtishad/xm/internal/security"
@@ -15,6 +16,13 @@ type AppConfig struct {
 	DB     DBConfig
 	JWT    *security.JWTManager
 	Server ServerConfig
+	Auth   AuthConfig
+	Outbox OutboxConfig
+	Purge  PurgeConfig
+
+	Idempotency  IdempotencyConfig
+	CompanyTypes CompanyTypesConfig
+	Events       EventsConfig
 }
 
 // DBConfig holds database connection parameters.
@@ -32,6 +40,47 @@ type ServerConfig struct {
 	GinMode string
 }
 
+// AuthConfig contains authentication settings beyond the JWT keys.
+// RevocationCacheTTL bounds how long a token revoked by another instance may still be accepted.
+type AuthConfig struct {
+	RevocationCacheTTL time.Duration
+}
+
+// OutboxConfig contains the outbox relay settings.
+// The relay is disabled when no Kafka brokers are configured.
+type OutboxConfig struct {
+	KafkaBrokers []string
+	KafkaTopic   string
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+// EventsConfig contains the settings of the CloudEvents envelope.
+// SchemaBaseURI is the absolute URI the dataschema attribute of every event starts with.
+type EventsConfig struct {
+	SchemaBaseURI string
+}
+
+// IdempotencyConfig contains the settings of Idempotency-Key handling.
+// KeyTTL is how long a stored response is replayed for retries carrying the same key.
+type IdempotencyConfig struct {
+	KeyTTL time.Duration
+}
+
+// CompanyTypesConfig contains the settings of company type validation.
+// CacheTTL bounds how long a type added or removed by another instance goes unnoticed.
+type CompanyTypesConfig struct {
+	CacheTTL time.Duration
+}
+
+// PurgeConfig contains the retention policy for soft-deleted companies.
+// Companies deleted longer than Retention ago are removed for good; a zero Retention disables the purge worker.
+type PurgeConfig struct {
+	Retention time.Duration
+	Interval  time.Duration
+	BatchSize int
+}
+
 // LoadConfig initializes the application configuration.
 func LoadConfig(l *slog.Logger) (*AppConfig, error) {
 	v := viper.New()
@@ -57,6 +106,29 @@ func LoadConfig(l *slog.Logger) (*AppConfig, error) {
 			Address: v.GetString("SERVER_ADDRESS"),
 			GinMode: v.GetString("GIN_MODE"),
 		},
+		Auth: AuthConfig{
+			RevocationCacheTTL: v.GetDuration("REVOCATION_CACHE_TTL"),
+		},
+		Outbox: OutboxConfig{
+			KafkaBrokers: splitList(v.GetString("KAFKA_BROKERS")),
+			KafkaTopic:   v.GetString("KAFKA_TOPIC"),
+			PollInterval: v.GetDuration("OUTBOX_POLL_INTERVAL"),
+			BatchSize:    v.GetInt("OUTBOX_BATCH_SIZE"),
+		},
+		Idempotency: IdempotencyConfig{
+			KeyTTL: v.GetDuration("IDEMPOTENCY_KEY_TTL"),
+		},
+		CompanyTypes: CompanyTypesConfig{
+			CacheTTL: v.GetDuration("COMPANY_TYPE_CACHE_TTL"),
+		},
+		Events: EventsConfig{
+			SchemaBaseURI: v.GetString("EVENT_SCHEMA_BASE_URI"),
+		},
+		Purge: PurgeConfig{
+			Retention: time.Duration(v.GetInt("PURGE_RETENTION_DAYS")) * 24 * time.Hour,
+			Interval:  v.GetDuration("PURGE_INTERVAL"),
+			BatchSize: v.GetInt("PURGE_BATCH_SIZE"),
+		},
 	}
 
 	var err error
@@ -70,11 +142,26 @@ func LoadConfig(l *slog.Logger) (*AppConfig, error) {
 
 // setupViper configures Viper to read from the env file.
 // Allows environment variables to override config file
+// Sets defaults for optional settings.
 func setupViper(v *viper.Viper) {
 	v.SetConfigName("app")
 	v.SetConfigType("env")
 	v.AddConfigPath(".")
 	v.AutomaticEnv()
+
+	v.SetDefault("JWT_ACCESS_TOKEN_TTL", 30*time.Minute)
+	v.SetDefault("JWT_REFRESH_TOKEN_TTL", 7*24*time.Hour)
+	v.SetDefault("REVOCATION_CACHE_TTL", 30*time.Second)
+	v.SetDefault("KAFKA_BROKERS", "")
+	v.SetDefault("KAFKA_TOPIC", "company-events")
+	v.SetDefault("OUTBOX_POLL_INTERVAL", time.Second)
+	v.SetDefault("OUTBOX_BATCH_SIZE", 100)
+	v.SetDefault("IDEMPOTENCY_KEY_TTL", 24*time.Hour)
+	v.SetDefault("COMPANY_TYPE_CACHE_TTL", time.Minute)
+	v.SetDefault("EVENT_SCHEMA_BASE_URI", "urn:xm:companies:schemas")
+	v.SetDefault("PURGE_RETENTION_DAYS", 0)
+	v.SetDefault("PURGE_INTERVAL", time.Hour)
+	v.SetDefault("PURGE_BATCH_SIZE", 100)
 }
 
 // checkRequiredConfigs ensures that all required configuration variables are present.
@@ -100,7 +187,7 @@ func checkRequiredConfigs(v *viper.Viper) error {
 }
 
 // setupJWTManager creates a new JWTManager with decoded keys from the configuration.
-// default access token duration 30 mins
+// Token lifetimes come from JWT_ACCESS_TOKEN_TTL (default 30 mins) and JWT_REFRESH_TOKEN_TTL (default 7 days).
 func setupJWTManager(v *viper.Viper) (*security.JWTManager, error) {
 	privateKeyB64 := v.GetString("JWT_PRIVATE_KEY")
 	publicKeyB64 := v.GetString("JWT_PUBLIC_KEY")
@@ -115,7 +202,18 @@ func setupJWTManager(v *viper.Viper) (*security.JWTManager, error) {
 		return nil, fmt.Errorf("failed to decode JWT public key: %w", err)
 	}
 
-	return security.NewJWTManager(30*time.Minute, privateKey, publicKey)
+	return security.NewJWTManager(v.GetDuration("JWT_ACCESS_TOKEN_TTL"), v.GetDuration("JWT_REFRESH_TOKEN_TTL"), privateKey, publicKey)
+}
+
+// splitList splits a comma-separated config value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // decodeBase64 decodes a base64-encoded string.
@@ -136,5 +234,18 @@ func logConfig(l *slog.Logger, config *AppConfig) {
 		"db_max_idle_conns", config.DB.MaxIdleConns,
 		"db_conn_max_lifetime", config.DB.ConnMaxLifetime,
 		"db_conn_max_idle_time", config.DB.ConnMaxIdleTime,
+		"jwt_access_token_ttl", config.JWT.AccessExp,
+		"jwt_refresh_token_ttl", config.JWT.RefreshExp,
+		"revocation_cache_ttl", config.Auth.RevocationCacheTTL,
+		"kafka_brokers", config.Outbox.KafkaBrokers,
+		"kafka_topic", config.Outbox.KafkaTopic,
+		"outbox_poll_interval", config.Outbox.PollInterval,
+		"outbox_batch_size", config.Outbox.BatchSize,
+		"idempotency_key_ttl", config.Idempotency.KeyTTL,
+		"company_type_cache_ttl", config.CompanyTypes.CacheTTL,
+		"event_schema_base_uri", config.Events.SchemaBaseURI,
+		"purge_retention", config.Purge.Retention,
+		"purge_interval", config.Purge.Interval,
+		"purge_batch_size", config.Purge.BatchSize,
 	)
 }
@@ -8,3 +8,5 @@ const (
 	DBColumnUserID = "user_id"
 	DBColumnEmail  = "email"
 )
+
+const DefaultPageLimit = 20
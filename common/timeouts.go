@@ -5,4 +5,5 @@ import "time"
 const (
 	TimeOutRegisterUser = 500 * time.Millisecond
 	TimeOutLogin        = 500 * time.Millisecond
+	TimeOutRefreshToken = 500 * time.Millisecond
 )
@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CloudEvents attributes shared by every event of the service.
+const (
+	CloudEventsSpecVersion = "1.0"
+	EventSource            = "/xm/companies"
+	EventDataContentType   = "application/json"
+)
+
+// Event types produced by the service.
+const (
+	EventCompanyCreated              = "company_created"
+	EventCompanyUpdated              = "company_updated"
+	EventCompanyDeleted              = "company_deleted"
+	EventCompanyRestored             = "company_restored"
+	EventCompanyPurged               = "company_purged"
+	EventCompanyOwnershipTransferred = "company_ownership_transferred"
+	EventCompanyParentChanged        = "company_parent_changed"
+	EventCompanyAddressAdded         = "company_address_added"
+	EventCompanyAddressUpdated       = "company_address_updated"
+	EventCompanyAddressRemoved       = "company_address_removed"
+	EventCompanyContactAdded         = "company_contact_added"
+	EventCompanyContactUpdated       = "company_contact_updated"
+	EventCompanyContactRemoved       = "company_contact_removed"
+	EventCompanyIdentifierAdded      = "company_identifier_added"
+	EventCompanyIdentifierRemoved    = "company_identifier_removed"
+
+	// Status changes, see companyTransitions.
+	EventCompanyRegistrationRequested = "company_registration_requested"
+	EventCompanyRegistrationWithdrawn = "company_registration_withdrawn"
+	EventCompanyRegistered            = "company_registered"
+	EventCompanySuspended             = "company_suspended"
+	EventCompanyReinstated            = "company_reinstated"
+	EventCompanyDissolved             = "company_dissolved"
+)
+
+// eventSchemaVersions holds the current payload schema version of every event type.
+// A breaking change to a payload must bump its version, so consumers can tell the shapes apart
+// through the dataschema attribute. Event types missing here can't be constructed.
+var eventSchemaVersions = map[string]int{
+	EventCompanyCreated:              1,
+	EventCompanyUpdated:              1,
+	EventCompanyDeleted:              1,
+	EventCompanyRestored:             1,
+	EventCompanyPurged:               1,
+	EventCompanyOwnershipTransferred: 1,
+	EventCompanyParentChanged:        1,
+	EventCompanyAddressAdded:         1,
+	EventCompanyAddressUpdated:       1,
+	EventCompanyAddressRemoved:       1,
+	EventCompanyContactAdded:         1,
+	EventCompanyContactUpdated:       1,
+	EventCompanyContactRemoved:       1,
+	EventCompanyIdentifierAdded:      1,
+	EventCompanyIdentifierRemoved:    1,
+
+	EventCompanyRegistrationRequested: 1,
+	EventCompanyRegistrationWithdrawn: 1,
+	EventCompanyRegistered:            1,
+	EventCompanySuspended:             1,
+	EventCompanyReinstated:            1,
+	EventCompanyDissolved:             1,
+}
+
+// CloudEvent is the envelope stored in the events outbox and published to consumers,
+// following the structured JSON format of the CloudEvents 1.0 specification.
+// Subject is the ID of the entity the event is about; CorrelationID is a CloudEvents extension
+// attribute linking the event to the request that caused it.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	Type            string          `json:"type"`
+	Source          string          `json:"source"`
+	Subject         string          `json:"subject"`
+	ID              uuid.UUID       `json:"id"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	DataSchema      string          `json:"dataschema"`
+	CorrelationID   string          `json:"correlationid,omitempty"`
+	Data            json.RawMessage `json:"data"`
+
+	AggregateID uuid.UUID `json:"-"`
+}
+
+// NewCloudEvent builds the envelope of an event about the aggregate with the given ID,
+// marshaling payload as its data. The correlation ID is taken from ctx when present.
+// Returns an error for event types without a registered schema version.
+func NewCloudEvent(ctx context.Context, eventType string, aggregateID uuid.UUID, payload any) (*CloudEvent, error) {
+	version, ok := eventSchemaVersions[eventType]
+	if !ok {
+		return nil, fmt.Errorf("no schema version registered for event type %s", eventType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s payload: %w", eventType, err)
+	}
+
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		Type:            eventType,
+		Source:          EventSource,
+		Subject:         aggregateID.String(),
+		ID:              uuid.New(),
+		Time:            time.Now().UTC(),
+		DataContentType: EventDataContentType,
+		DataSchema:      EventDataSchema(eventType, version),
+		CorrelationID:   CorrelationIDFromContext(ctx),
+		Data:            data,
+		AggregateID:     aggregateID,
+	}, nil
+}
+
+// DefaultEventSchemaBase is the base of the dataschema URIs unless EVENT_SCHEMA_BASE_URI configures another.
+// It only names the schemas, nothing resolves it.
+const DefaultEventSchemaBase = "urn:xm:companies:schemas"
+
+// eventSchemaBase is the base of the dataschema URIs, set once at startup by SetEventSchemaBase.
+var eventSchemaBase = DefaultEventSchemaBase
+
+// SetEventSchemaBase sets the base of the dataschema URIs of events created from now on, e.g.
+// https://schemas.example.com/xm or a urn:. CloudEvents requires dataschema to be an absolute URI.
+func SetEventSchemaBase(base string) error {
+	if u, err := url.Parse(base); err != nil || !u.IsAbs() {
+		return fmt.Errorf("event schema base %q is not an absolute URI", base)
+	}
+
+	eventSchemaBase = strings.TrimRight(base, "/:")
+	return nil
+}
+
+// EventDataSchema returns the dataschema URI of a version of an event type's payload. Path segments
+// are joined with colons under a urn: base and with slashes otherwise.
+func EventDataSchema(eventType string, version int) string {
+	separator := "/"
+	if strings.HasPrefix(eventSchemaBase, "urn:") {
+		separator = ":"
+	}
+
+	return fmt.Sprintf("%s%s%s%sv%d", eventSchemaBase, separator, eventType, separator, version)
+}
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a context carrying the correlation ID of the current request.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation ID stored by WithCorrelationID, or an empty string.
+func CorrelationIDFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationID
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewCloudEvent(t *testing.T) {
+	companyID := uuid.New()
+	ctx := WithCorrelationID(context.Background(), "req-123")
+
+	event, err := NewCloudEvent(ctx, EventCompanyCreated, companyID, Company{ID: companyID, Name: "TechCorp"})
+	if err != nil {
+		t.Fatalf("NewCloudEvent() unexpected error = %v", err)
+	}
+
+	if event.SpecVersion != CloudEventsSpecVersion || event.Type != EventCompanyCreated || event.Source != EventSource {
+		t.Errorf("NewCloudEvent() got specversion = %v, type = %v, source = %v", event.SpecVersion, event.Type, event.Source)
+	}
+
+	if event.Subject != companyID.String() || event.AggregateID != companyID {
+		t.Errorf("NewCloudEvent() got subject = %v, aggregateID = %v, want %v", event.Subject, event.AggregateID, companyID)
+	}
+
+	if event.DataSchema != "urn:xm:companies:schemas:company_created:v1" {
+		t.Errorf("NewCloudEvent() got dataschema = %v", event.DataSchema)
+	}
+
+	if u, err := url.Parse(event.DataSchema); err != nil || !u.IsAbs() {
+		t.Errorf("NewCloudEvent() got dataschema = %v, want an absolute URI", event.DataSchema)
+	}
+
+	if event.CorrelationID != "req-123" {
+		t.Errorf("NewCloudEvent() got correlationid = %v, want req-123", event.CorrelationID)
+	}
+
+	var envelope map[string]any
+	raw, _ := json.Marshal(event)
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		t.Fatalf("json.Unmarshal() unexpected error = %v", err)
+	}
+
+	for _, attr := range []string{"specversion", "type", "source", "subject", "id", "time", "datacontenttype", "dataschema", "data"} {
+		if _, ok := envelope[attr]; !ok {
+			t.Errorf("envelope is missing the %s attribute", attr)
+		}
+	}
+
+	if data, _ := envelope["data"].(map[string]any); data["name"] != "TechCorp" {
+		t.Errorf("envelope data got = %v, want the company", envelope["data"])
+	}
+}
+
+func TestNewCloudEvent_UnknownType(t *testing.T) {
+	if _, err := NewCloudEvent(context.Background(), "company_renamed", uuid.New(), nil); err == nil {
+		t.Errorf("NewCloudEvent() error = nil, expected an error for an unregistered event type")
+	}
+}
+
+func TestEventDataSchema(t *testing.T) {
+	t.Cleanup(func() { eventSchemaBase = DefaultEventSchemaBase })
+
+	tests := []struct {
+		base    string
+		want    string
+		wantErr bool
+	}{
+		{"urn:xm:companies:schemas", "urn:xm:companies:schemas:company_created:v2", false},
+		{"https://schemas.example.com/xm/", "https://schemas.example.com/xm/company_created/v2", false},
+		{"/xm/companies/schemas", "", true},
+		{"schemas.example.com", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.base, func(t *testing.T) {
+			eventSchemaBase = DefaultEventSchemaBase
+
+			if err := SetEventSchemaBase(tt.base); (err != nil) != tt.wantErr {
+				t.Fatalf("SetEventSchemaBase() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := EventDataSchema(EventCompanyCreated, 2)
+			if got != tt.want {
+				t.Errorf("EventDataSchema() got = %v, want %v", got, tt.want)
+			}
+
+			if u, err := url.Parse(got); err != nil || !u.IsAbs() {
+				t.Errorf("EventDataSchema() got = %v, want an absolute URI", got)
+			}
+		})
+	}
+}
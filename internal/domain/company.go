@@ -1,17 +1,16 @@
 package domain
 
 import (
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/ashtishad/xm/common"
 	"github.com/google/uuid"
 )
 
-const (
-	CompanyTypeCorporation        = "Corporations"
-	CompanyTypeNonProfit          = "NonProfit"
-	CompanyTypeCooperative        = "Cooperative"
-	CompanyTypeSoleProprietorship = "Sole Proprietorship"
-)
+// CompanyNameMaxLength is the maximum length of a company name, in characters.
+const CompanyNameMaxLength = 15
 
 type Company struct {
 	ID                uuid.UUID  `json:"id"`
@@ -19,8 +18,173 @@ type Company struct {
 	Description       *string    `json:"description,omitempty"`
 	AmountOfEmployees int        `json:"amountOfEmployees"`
 	Registered        bool       `json:"registered"`
+	Status            string     `json:"status"`
 	Type              string     `json:"type"`
+	OwnerID           *uuid.UUID `json:"ownerId,omitempty"`
+	ParentID          *uuid.UUID `json:"parentId,omitempty"`
+	Version           int        `json:"version"`
 	CreatedAt         *time.Time `json:"createdAt"`
 	UpdatedAt         *time.Time `json:"updatedAt"`
 	DeletedAt         *time.Time `json:"deletedAt,omitempty"`
 }
+
+// nameVariantSuffixes are appended to a taken company name to suggest alternatives, in order of preference.
+var nameVariantSuffixes = []string{" Group", " Co", " Inc", " Labs", " HQ", " 2", " 3", " 4", " 5"}
+
+// NameVariants returns alternatives to a company name, each at most CompanyNameMaxLength characters
+// long. Names too long for a suffix are shortened to make room for it.
+// Variants are unique ignoring case, and differ from name ignoring case.
+func NameVariants(name string) []string {
+	base := strings.TrimSpace(name)
+	seen := map[string]bool{strings.ToLower(name): true}
+	variants := make([]string, 0, len(nameVariantSuffixes))
+
+	for _, suffix := range nameVariantSuffixes {
+		room := CompanyNameMaxLength - utf8.RuneCountInString(suffix)
+		variant := strings.TrimSpace(truncateRunes(base, room)) + suffix
+
+		if key := strings.ToLower(variant); !seen[key] {
+			seen[key] = true
+			variants = append(variants, variant)
+		}
+	}
+
+	return variants
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n])
+}
+
+// CompanyFilter holds the optional criteria used to list companies.
+// Nil fields are ignored. AfterID is the keyset cursor: only companies with an id
+// greater than it are returned, in ascending id order.
+// Soft-deleted companies only match when IncludeDeleted is set.
+type CompanyFilter struct {
+	Type           *string
+	Registered     *bool
+	Status         *string
+	MinEmployees   *int
+	MaxEmployees   *int
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	OwnerID        *uuid.UUID
+	AfterID        *uuid.UUID
+	IncludeDeleted bool
+	Limit          int
+}
+
+// CompanyPage is a single page of a keyset-paginated company listing.
+// HasMore reports whether another page follows the last company in Companies.
+type CompanyPage struct {
+	Companies []Company
+	HasMore   bool
+}
+
+// Delimiters of the matched words within CompanySearchResult.DescriptionSnippet. Control characters
+// can't be mistaken for description text, so callers can escape the snippet and mark the matches safely.
+const (
+	SearchMatchStart = "\x02"
+	SearchMatchStop  = "\x03"
+)
+
+// RelatedCompany is a company within the hierarchy of another one. Depth is its distance from
+// that company: 1 for its parent or direct subsidiaries, 2 for the next level, and so on.
+type RelatedCompany struct {
+	Company
+	Depth int `json:"depth"`
+}
+
+// CompanySearchResult is a company matching a search. Score ranks the results, combining the
+// trigram similarity of the name with the full-text rank of the description.
+// DescriptionSnippet holds the best fragments of a matching description, with matched words
+// between SearchMatchStart and SearchMatchStop; it is empty when only the name matched.
+type CompanySearchResult struct {
+	Company            Company
+	Score              float64
+	DescriptionSnippet string
+}
+
+// CompanyOperationType is the kind of change a CompanyOperation applies.
+type CompanyOperationType string
+
+const (
+	CompanyOperationCreate CompanyOperationType = "create"
+	CompanyOperationUpdate CompanyOperationType = "update"
+	CompanyOperationDelete CompanyOperationType = "delete"
+)
+
+// CompanyOperation is a single change within a batch. Company is the company to insert for
+// creates; ID and Updates address the company to modify for updates, ID alone for deletes.
+// ExpectedVersion optionally guards updates and deletes, like an If-Match header, and Cascade
+// deletes the subsidiaries of a deleted company along with it.
+type CompanyOperation struct {
+	Type            CompanyOperationType
+	ID              uuid.UUID
+	Company         *Company
+	Updates         map[string]any
+	ExpectedVersion *int
+	Cascade         bool
+}
+
+// CompanyOperationResult is the outcome of a CompanyOperation: the affected company, or the
+// error that made it fail. Both are nil for operations never attempted because an earlier
+// operation aborted an atomic batch.
+type CompanyOperationResult struct {
+	Company *Company
+	Err     common.AppError
+}
+
+// FieldChange holds the value of a company field before and after an update.
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// DiffCompanies returns the user editable fields that differ between before and after,
+// keyed by their JSON name. Timestamps, ownership and the parent are not compared.
+func DiffCompanies(before, after *Company) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+
+	if before.Name != after.Name {
+		changes["name"] = FieldChange{From: before.Name, To: after.Name}
+	}
+
+	if !equalStringPtr(before.Description, after.Description) {
+		changes["description"] = FieldChange{From: before.Description, To: after.Description}
+	}
+
+	if before.AmountOfEmployees != after.AmountOfEmployees {
+		changes["amountOfEmployees"] = FieldChange{From: before.AmountOfEmployees, To: after.AmountOfEmployees}
+	}
+
+	if before.Registered != after.Registered {
+		changes["registered"] = FieldChange{From: before.Registered, To: after.Registered}
+	}
+
+	if before.Type != after.Type {
+		changes["type"] = FieldChange{From: before.Type, To: after.Type}
+	}
+
+	return changes
+}
+
+func equalUUIDPtr(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
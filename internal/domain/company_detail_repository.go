@@ -0,0 +1,471 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/ashtishad/xm/common"
+	"github.com/google/uuid"
+)
+
+// CompanyDetailRepository defines the interface for the addresses, contacts and registration
+// identifiers of companies. Every mutation requires an active company and produces an event
+// in the company's event stream.
+type CompanyDetailRepository interface {
+	ListAddresses(ctx context.Context, companyID uuid.UUID) ([]CompanyAddress, common.AppError)
+	CreateAddress(ctx context.Context, address *CompanyAddress) (*CompanyAddress, common.AppError)
+	UpdateAddress(ctx context.Context, companyID uuid.UUID, id uuid.UUID, updates map[string]any) (*CompanyAddress, common.AppError)
+	DeleteAddress(ctx context.Context, companyID uuid.UUID, id uuid.UUID) common.AppError
+	ListContacts(ctx context.Context, companyID uuid.UUID) ([]CompanyContact, common.AppError)
+	CreateContact(ctx context.Context, contact *CompanyContact) (*CompanyContact, common.AppError)
+	UpdateContact(ctx context.Context, companyID uuid.UUID, id uuid.UUID, updates map[string]any) (*CompanyContact, common.AppError)
+	DeleteContact(ctx context.Context, companyID uuid.UUID, id uuid.UUID) common.AppError
+	ListIdentifiers(ctx context.Context, companyID uuid.UUID) ([]CompanyIdentifier, common.AppError)
+	CreateIdentifier(ctx context.Context, identifier *CompanyIdentifier) (*CompanyIdentifier, common.AppError)
+	DeleteIdentifier(ctx context.Context, companyID uuid.UUID, id uuid.UUID) common.AppError
+}
+
+// Columns read by scanAddress, scanContact and scanIdentifier, in order.
+const (
+	addressColumns    = `id, company_id, kind, line1, line2, city, postal_code, region, country, created_at, updated_at`
+	contactColumns    = `id, company_id, name, role, email, phone, created_at, updated_at`
+	identifierColumns = `id, company_id, scheme, value, created_at`
+)
+
+const (
+	errAddressNotFound     = "address not found"
+	errContactNotFound     = "contact not found"
+	errIdentifierNotFound  = "identifier not found"
+	errRegisteredAddress   = "company already has a registered address"
+	errIdentifierDuplicate = "identifier is already registered to a company"
+)
+
+type companyDetailRepository struct {
+	db              *sql.DB
+	l               *slog.Logger
+	eventRepository EventRepository
+}
+
+// NewCompanyDetailRepository creates a new instance of CompanyDetailRepository.
+func NewCompanyDetailRepository(db *sql.DB, logger *slog.Logger, eventRepo EventRepository) CompanyDetailRepository {
+	return &companyDetailRepository{
+		db:              db,
+		l:               logger,
+		eventRepository: eventRepo,
+	}
+}
+
+// ListAddresses returns the addresses of a company, oldest first.
+func (r *companyDetailRepository) ListAddresses(ctx context.Context, companyID uuid.UUID) ([]CompanyAddress, common.AppError) {
+	query := `SELECT ` + addressColumns + ` FROM company_addresses WHERE company_id = $1 ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, companyID)
+	if err != nil {
+		r.l.Error("failed to list company addresses", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	addresses := make([]CompanyAddress, 0)
+	for rows.Next() {
+		address, err := scanAddress(rows)
+		if err != nil {
+			r.l.Error("failed to scan company address", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+		addresses = append(addresses, *address)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.l.Error("failed to iterate company addresses", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return addresses, nil
+}
+
+// CreateAddress adds an address to its company.
+// Returns ConflictError for a second registered address.
+// Produces company_address_added event in the same transaction.
+func (r *companyDetailRepository) CreateAddress(ctx context.Context, address *CompanyAddress) (*CompanyAddress, common.AppError) {
+	query := `
+        INSERT INTO company_addresses (id, company_id, kind, line1, line2, city, postal_code, region, country)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING ` + addressColumns + `
+    `
+
+	var created *CompanyAddress
+	appErr := r.inCompanyTx(ctx, address.CompanyID, func(tx *sql.Tx) common.AppError {
+		var err error
+		created, err = scanAddress(tx.QueryRowContext(ctx, query, address.ID, address.CompanyID, address.Kind,
+			address.Line1, address.Line2, address.City, address.PostalCode, address.Region, address.Country))
+		if err != nil {
+			if pgErrorCode(err) == pgCodeUniqueViolation {
+				return common.NewConflictError(errRegisteredAddress)
+			}
+			r.l.Error("failed to create company address", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		return r.storeDetailEvent(ctx, tx, EventCompanyAddressAdded, created.CompanyID, created)
+	})
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return created, nil
+}
+
+// UpdateAddress modifies an address of a company.
+// Returns NotFoundError if the company has no such address, and ConflictError for a second registered address.
+// Produces company_address_updated event in the same transaction.
+func (r *companyDetailRepository) UpdateAddress(ctx context.Context, companyID uuid.UUID, id uuid.UUID,
+	updates map[string]any) (*CompanyAddress, common.AppError) {
+	setClause, args := buildUpdateQuery(updates)
+	args = append(args, id, companyID)
+	query := fmt.Sprintf(`
+        UPDATE company_addresses
+        SET %s
+        WHERE id = $%d AND company_id = $%d
+        RETURNING %s
+    `, setClause, len(args)-1, len(args), addressColumns)
+
+	var updated *CompanyAddress
+	appErr := r.inCompanyTx(ctx, companyID, func(tx *sql.Tx) common.AppError {
+		var err error
+		updated, err = scanAddress(tx.QueryRowContext(ctx, query, args...))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return common.NewNotFoundError(errAddressNotFound)
+			}
+			if pgErrorCode(err) == pgCodeUniqueViolation {
+				return common.NewConflictError(errRegisteredAddress)
+			}
+			r.l.Error("failed to update company address", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		return r.storeDetailEvent(ctx, tx, EventCompanyAddressUpdated, companyID, updated)
+	})
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return updated, nil
+}
+
+// DeleteAddress removes an address of a company.
+// Returns NotFoundError if the company has no such address.
+// Produces company_address_removed event in the same transaction, carrying the removed address.
+func (r *companyDetailRepository) DeleteAddress(ctx context.Context, companyID uuid.UUID, id uuid.UUID) common.AppError {
+	query := `DELETE FROM company_addresses WHERE id = $1 AND company_id = $2 RETURNING ` + addressColumns
+
+	return r.inCompanyTx(ctx, companyID, func(tx *sql.Tx) common.AppError {
+		removed, err := scanAddress(tx.QueryRowContext(ctx, query, id, companyID))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return common.NewNotFoundError(errAddressNotFound)
+			}
+			r.l.Error("failed to delete company address", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		return r.storeDetailEvent(ctx, tx, EventCompanyAddressRemoved, companyID, removed)
+	})
+}
+
+// ListContacts returns the contacts of a company, oldest first.
+func (r *companyDetailRepository) ListContacts(ctx context.Context, companyID uuid.UUID) ([]CompanyContact, common.AppError) {
+	query := `SELECT ` + contactColumns + ` FROM company_contacts WHERE company_id = $1 ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, companyID)
+	if err != nil {
+		r.l.Error("failed to list company contacts", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	contacts := make([]CompanyContact, 0)
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			r.l.Error("failed to scan company contact", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+		contacts = append(contacts, *contact)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.l.Error("failed to iterate company contacts", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return contacts, nil
+}
+
+// CreateContact adds a contact to its company.
+// Produces company_contact_added event in the same transaction.
+func (r *companyDetailRepository) CreateContact(ctx context.Context, contact *CompanyContact) (*CompanyContact, common.AppError) {
+	query := `
+        INSERT INTO company_contacts (id, company_id, name, role, email, phone)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING ` + contactColumns + `
+    `
+
+	var created *CompanyContact
+	appErr := r.inCompanyTx(ctx, contact.CompanyID, func(tx *sql.Tx) common.AppError {
+		var err error
+		created, err = scanContact(tx.QueryRowContext(ctx, query, contact.ID, contact.CompanyID,
+			contact.Name, contact.Role, contact.Email, contact.Phone))
+		if err != nil {
+			r.l.Error("failed to create company contact", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		return r.storeDetailEvent(ctx, tx, EventCompanyContactAdded, created.CompanyID, created)
+	})
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return created, nil
+}
+
+// UpdateContact modifies a contact of a company.
+// Returns NotFoundError if the company has no such contact.
+// Produces company_contact_updated event in the same transaction.
+func (r *companyDetailRepository) UpdateContact(ctx context.Context, companyID uuid.UUID, id uuid.UUID,
+	updates map[string]any) (*CompanyContact, common.AppError) {
+	setClause, args := buildUpdateQuery(updates)
+	args = append(args, id, companyID)
+	query := fmt.Sprintf(`
+        UPDATE company_contacts
+        SET %s
+        WHERE id = $%d AND company_id = $%d
+        RETURNING %s
+    `, setClause, len(args)-1, len(args), contactColumns)
+
+	var updated *CompanyContact
+	appErr := r.inCompanyTx(ctx, companyID, func(tx *sql.Tx) common.AppError {
+		var err error
+		updated, err = scanContact(tx.QueryRowContext(ctx, query, args...))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return common.NewNotFoundError(errContactNotFound)
+			}
+			r.l.Error("failed to update company contact", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		return r.storeDetailEvent(ctx, tx, EventCompanyContactUpdated, companyID, updated)
+	})
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return updated, nil
+}
+
+// DeleteContact removes a contact of a company.
+// Returns NotFoundError if the company has no such contact.
+// Produces company_contact_removed event in the same transaction, carrying the removed contact.
+func (r *companyDetailRepository) DeleteContact(ctx context.Context, companyID uuid.UUID, id uuid.UUID) common.AppError {
+	query := `DELETE FROM company_contacts WHERE id = $1 AND company_id = $2 RETURNING ` + contactColumns
+
+	return r.inCompanyTx(ctx, companyID, func(tx *sql.Tx) common.AppError {
+		removed, err := scanContact(tx.QueryRowContext(ctx, query, id, companyID))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return common.NewNotFoundError(errContactNotFound)
+			}
+			r.l.Error("failed to delete company contact", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		return r.storeDetailEvent(ctx, tx, EventCompanyContactRemoved, companyID, removed)
+	})
+}
+
+// ListIdentifiers returns the registration identifiers of a company, ordered by scheme.
+func (r *companyDetailRepository) ListIdentifiers(ctx context.Context, companyID uuid.UUID) ([]CompanyIdentifier, common.AppError) {
+	query := `SELECT ` + identifierColumns + ` FROM company_identifiers WHERE company_id = $1 ORDER BY scheme, created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, companyID)
+	if err != nil {
+		r.l.Error("failed to list company identifiers", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	identifiers := make([]CompanyIdentifier, 0)
+	for rows.Next() {
+		identifier, err := scanIdentifier(rows)
+		if err != nil {
+			r.l.Error("failed to scan company identifier", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+		identifiers = append(identifiers, *identifier)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.l.Error("failed to iterate company identifiers", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return identifiers, nil
+}
+
+// CreateIdentifier adds a registration identifier to its company. The value must already be
+// normalized and validated, see NormalizeIdentifier and ValidateIdentifier.
+// Returns ConflictError if the identifier belongs to any company, including deleted ones.
+// Produces company_identifier_added event in the same transaction.
+func (r *companyDetailRepository) CreateIdentifier(ctx context.Context, identifier *CompanyIdentifier) (*CompanyIdentifier, common.AppError) {
+	query := `
+        INSERT INTO company_identifiers (id, company_id, scheme, value)
+        VALUES ($1, $2, $3, $4)
+        RETURNING ` + identifierColumns + `
+    `
+
+	var created *CompanyIdentifier
+	appErr := r.inCompanyTx(ctx, identifier.CompanyID, func(tx *sql.Tx) common.AppError {
+		var err error
+		created, err = scanIdentifier(tx.QueryRowContext(ctx, query, identifier.ID, identifier.CompanyID,
+			identifier.Scheme, identifier.Value))
+		if err != nil {
+			if pgErrorCode(err) == pgCodeUniqueViolation {
+				return common.NewConflictError(errIdentifierDuplicate)
+			}
+			r.l.Error("failed to create company identifier", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		return r.storeDetailEvent(ctx, tx, EventCompanyIdentifierAdded, created.CompanyID, created)
+	})
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return created, nil
+}
+
+// DeleteIdentifier removes a registration identifier of a company.
+// Returns NotFoundError if the company has no such identifier.
+// Produces company_identifier_removed event in the same transaction, carrying the removed identifier.
+func (r *companyDetailRepository) DeleteIdentifier(ctx context.Context, companyID uuid.UUID, id uuid.UUID) common.AppError {
+	query := `DELETE FROM company_identifiers WHERE id = $1 AND company_id = $2 RETURNING ` + identifierColumns
+
+	return r.inCompanyTx(ctx, companyID, func(tx *sql.Tx) common.AppError {
+		removed, err := scanIdentifier(tx.QueryRowContext(ctx, query, id, companyID))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return common.NewNotFoundError(errIdentifierNotFound)
+			}
+			r.l.Error("failed to delete company identifier", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		return r.storeDetailEvent(ctx, tx, EventCompanyIdentifierRemoved, companyID, removed)
+	})
+}
+
+// inCompanyTx runs fn in a serializable transaction holding a share lock on the company, so it can't
+// be deleted until the transaction ends, and commits it when fn succeeds.
+// Returns NotFoundError if the company doesn't exist or is deleted.
+func (r *companyDetailRepository) inCompanyTx(ctx context.Context, companyID uuid.UUID, fn func(tx *sql.Tx) common.AppError) common.AppError {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		r.l.Error(common.ErrTXBegin, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer r.rollBackOnError(tx)
+
+	var id uuid.UUID
+	err = tx.QueryRowContext(ctx, "SELECT id FROM companies WHERE id = $1 AND deleted_at IS NULL FOR SHARE", companyID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return common.NewNotFoundError("company not found")
+		}
+		r.l.Error("failed to lock company", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if appErr := fn(tx); appErr != nil {
+		return appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.l.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// scanAddress reads a row selected with addressColumns into a CompanyAddress.
+func scanAddress(row rowScanner) (*CompanyAddress, error) {
+	var address CompanyAddress
+	var line2, postalCode, region sql.NullString
+
+	err := row.Scan(&address.ID, &address.CompanyID, &address.Kind, &address.Line1, &line2, &address.City,
+		&postalCode, &region, &address.Country, &address.CreatedAt, &address.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	address.Line2 = nullStringPtr(line2)
+	address.PostalCode = nullStringPtr(postalCode)
+	address.Region = nullStringPtr(region)
+
+	return &address, nil
+}
+
+// scanContact reads a row selected with contactColumns into a CompanyContact.
+func scanContact(row rowScanner) (*CompanyContact, error) {
+	var contact CompanyContact
+	var role, email, phone sql.NullString
+
+	err := row.Scan(&contact.ID, &contact.CompanyID, &contact.Name, &role, &email, &phone,
+		&contact.CreatedAt, &contact.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	contact.Role = nullStringPtr(role)
+	contact.Email = nullStringPtr(email)
+	contact.Phone = nullStringPtr(phone)
+
+	return &contact, nil
+}
+
+// scanIdentifier reads a row selected with identifierColumns into a CompanyIdentifier.
+func scanIdentifier(row rowScanner) (*CompanyIdentifier, error) {
+	var identifier CompanyIdentifier
+
+	err := row.Scan(&identifier.ID, &identifier.CompanyID, &identifier.Scheme, &identifier.Value, &identifier.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identifier, nil
+}
+
+// storeDetailEvent records an event about a company's details in the same transaction as the
+// mutation, in the event stream of the company. A failure rolls the whole transaction back.
+func (r *companyDetailRepository) storeDetailEvent(ctx context.Context, tx *sql.Tx, eventType string, companyID uuid.UUID, payload any) common.AppError {
+	event, err := NewCloudEvent(ctx, eventType, companyID, payload)
+	if err != nil {
+		r.l.Error("failed to build company event", "eventType", eventType, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedEvent, err)
+	}
+
+	return r.eventRepository.StoreEvent(ctx, tx, event)
+}
+
+// rollBackOnError attempts to roll back a transaction if an error occurred.
+func (r *companyDetailRepository) rollBackOnError(tx *sql.Tx) {
+	if rbErr := tx.Rollback(); rbErr != nil && rbErr != sql.ErrTxDone {
+		r.l.Error(common.ErrTXRollback, "rbErr", rbErr)
+	}
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AddressKindRegistered = "registered"
+	AddressKindOperating  = "operating"
+)
+
+const (
+	IdentifierSchemeVAT = "vat"
+	IdentifierSchemeLEI = "lei"
+)
+
+// CompanyAddress is a postal address of a company. A company has at most one registered address
+// and any number of operating ones. Country is an ISO 3166-1 alpha-2 code.
+type CompanyAddress struct {
+	ID         uuid.UUID  `json:"id"`
+	CompanyID  uuid.UUID  `json:"companyId"`
+	Kind       string     `json:"kind"`
+	Line1      string     `json:"line1"`
+	Line2      *string    `json:"line2,omitempty"`
+	City       string     `json:"city"`
+	PostalCode *string    `json:"postalCode,omitempty"`
+	Region     *string    `json:"region,omitempty"`
+	Country    string     `json:"country"`
+	CreatedAt  *time.Time `json:"createdAt"`
+	UpdatedAt  *time.Time `json:"updatedAt"`
+}
+
+// CompanyContact is a person to get in touch with at a company.
+type CompanyContact struct {
+	ID        uuid.UUID  `json:"id"`
+	CompanyID uuid.UUID  `json:"companyId"`
+	Name      string     `json:"name"`
+	Role      *string    `json:"role,omitempty"`
+	Email     *string    `json:"email,omitempty"`
+	Phone     *string    `json:"phone,omitempty"`
+	CreatedAt *time.Time `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt"`
+}
+
+// CompanyIdentifier is a registration identifier of a company, such as its VAT number or its LEI.
+// Values are stored normalized, see NormalizeIdentifier, and designate a single company per scheme.
+type CompanyIdentifier struct {
+	ID        uuid.UUID  `json:"id"`
+	CompanyID uuid.UUID  `json:"companyId"`
+	Scheme    string     `json:"scheme"`
+	Value     string     `json:"value"`
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+var (
+	leiPattern = regexp.MustCompile(`^[0-9A-Z]{18}[0-9]{2}$`)
+	vatPattern = regexp.MustCompile(`^[A-Z]{2}[0-9A-Z]{2,13}$`)
+)
+
+// NormalizeIdentifier returns value in upper case without the spaces, dots and dashes
+// identifiers are commonly written with, e.g. "de 123.456.789" becomes "DE123456789".
+func NormalizeIdentifier(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '.', '-':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(value))
+}
+
+// ValidateIdentifier checks a normalized identifier value against the format of its scheme.
+// An LEI is 20 alphanumeric characters whose last two are check digits verified with ISO 7064 MOD 97-10,
+// as specified by ISO 17442. A VAT number is a two letter country prefix followed by 2 to 13 characters;
+// its check digits are country specific and left to the tax authorities' lookup services.
+func ValidateIdentifier(scheme, value string) error {
+	switch scheme {
+	case IdentifierSchemeLEI:
+		if !leiPattern.MatchString(value) {
+			return errors.New("LEI must be 18 letters or digits followed by 2 check digits")
+		}
+
+		if mod97(value) != 1 {
+			return errors.New("LEI check digits are invalid")
+		}
+	case IdentifierSchemeVAT:
+		if !vatPattern.MatchString(value) {
+			return errors.New("VAT number must be a 2 letter country prefix followed by 2 to 13 letters or digits")
+		}
+	default:
+		return errors.New("unknown identifier scheme " + scheme)
+	}
+
+	return nil
+}
+
+// mod97 returns the remainder of the division by 97 of an alphanumeric value read as a number,
+// with letters standing for 10 (A) to 35 (Z). value must only hold digits and upper case letters.
+func mod97(value string) int {
+	remainder := 0
+
+	for _, r := range value {
+		if r >= 'A' {
+			n := int(r-'A') + 10
+			remainder = (remainder*100 + n) % 97
+		} else {
+			remainder = (remainder*10 + int(r-'0')) % 97
+		}
+	}
+
+	return remainder
+}
@@ -0,0 +1,51 @@
+package domain
+
+import "testing"
+
+func TestNormalizeIdentifier(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"DE123456789", "DE123456789"},
+		{"de 123.456.789", "DE123456789"},
+		{"5493-001K-JTII-GC8Y-1R12", "5493001KJTIIGC8Y1R12"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeIdentifier(tt.value); got != tt.want {
+			t.Errorf("NormalizeIdentifier(%q) got = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestValidateIdentifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		scheme  string
+		value   string
+		wantErr bool
+	}{
+		{"Valid LEI", IdentifierSchemeLEI, "5493001KJTIIGC8Y1R12", false},
+		{"Valid LEI with letters in the check range", IdentifierSchemeLEI, "7H6GLXDRUGQFU57RNE97", false},
+		{"LEI with wrong check digits", IdentifierSchemeLEI, "5493001KJTIIGC8Y1R13", true},
+		{"LEI with a transposition", IdentifierSchemeLEI, "4593001KJTIIGC8Y1R12", true},
+		{"LEI too short", IdentifierSchemeLEI, "5493001KJTIIGC8Y1R1", true},
+		{"LEI with letter check digits", IdentifierSchemeLEI, "5493001KJTIIGC8Y1RAB", true},
+		{"LEI in lower case", IdentifierSchemeLEI, "5493001kjtiigc8y1r12", true},
+		{"Valid VAT number", IdentifierSchemeVAT, "DE123456789", false},
+		{"Valid VAT number with letters", IdentifierSchemeVAT, "NL123456789B01", false},
+		{"VAT number without country prefix", IdentifierSchemeVAT, "123456789", true},
+		{"VAT number too long", IdentifierSchemeVAT, "FR12345678901234", true},
+		{"Unknown scheme", "duns", "123456789", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateIdentifier(tt.scheme, tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIdentifier() got error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
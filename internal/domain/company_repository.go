@@ -3,7 +3,6 @@ package domain
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -11,17 +10,42 @@ import (
 
 	"github.com/ashtishad/xm/common"
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 )
 
 // CompanyRepository defines the interface for company data operations.
 type CompanyRepository interface {
 	Create(ctx context.Context, company *Company) (*Company, common.AppError)
 	FindByID(ctx context.Context, id uuid.UUID) (*Company, common.AppError)
-	Update(ctx context.Context, id uuid.UUID, updates map[string]any) (*Company, common.AppError)
-	Delete(ctx context.Context, id uuid.UUID) common.AppError
+	Update(ctx context.Context, id uuid.UUID, updates map[string]any, expectedVersion *int) (*Company, common.AppError)
+	Delete(ctx context.Context, id uuid.UUID, expectedVersion *int, cascade bool) common.AppError
+	List(ctx context.Context, filter CompanyFilter) (*CompanyPage, common.AppError)
+	TransferOwnership(ctx context.Context, id uuid.UUID, newOwnerID uuid.UUID) (*Company, common.AppError)
+	Restore(ctx context.Context, id uuid.UUID) (*Company, common.AppError)
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int, common.AppError)
+	Export(ctx context.Context, filter CompanyFilter, fn func(*Company) error) common.AppError
+	Search(ctx context.Context, query string, limit int) ([]CompanySearchResult, common.AppError)
+	TakenNames(ctx context.Context, names []string) ([]string, common.AppError)
+	SetParent(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) (*Company, common.AppError)
+	Subsidiaries(ctx context.Context, id uuid.UUID, recursive bool) ([]RelatedCompany, common.AppError)
+	Ancestors(ctx context.Context, id uuid.UUID) ([]RelatedCompany, common.AppError)
+	Batch(ctx context.Context, ops []CompanyOperation, atomic bool) ([]CompanyOperationResult, common.AppError)
+	Transition(ctx context.Context, id uuid.UUID, status string, reason string, expectedVersion *int) (*Company, common.AppError)
 }
 
+// companyColumns lists the columns read by scanCompany, in order.
+const companyColumns = `id, name, description, amount_of_employees, registered, status, type, owner_id, parent_id, version, created_at, updated_at, deleted_at`
+
+// errVersionMismatch is the message of the PreconditionFailedError returned when a company
+// was modified since the version the caller expected.
+const errVersionMismatch = "company has been modified since it was last fetched"
+
+// errUnknownCompanyType is the message of the BadRequestError returned for companies of a type
+// missing from the company_types table.
+const errUnknownCompanyType = "company type does not exist"
+
+// exportFetchSize is the number of rows Export fetches from its cursor at a time.
+const exportFetchSize = 500
+
 type companyRepository struct {
 	db              *sql.DB
 	l               *slog.Logger
@@ -39,9 +63,10 @@ func NewCompanyRepository(db *sql.DB, logger *slog.Logger, eventRepo EventReposi
 
 // Create inserts a new company record into the database.
 // Performs a case-insensitive check for existing company names before insertion.
+// Names of soft-deleted companies are free to be reused.
 // Handles potential race conditions by catching unique constraint violations.
 // Uses a serializable transaction to ensure data consistency.
-// Produces company_created event
+// Produces company_created event in the same transaction; a failed event write rolls back the insert.
 func (r *companyRepository) Create(ctx context.Context, company *Company) (*Company, common.AppError) {
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
@@ -50,46 +75,63 @@ func (r *companyRepository) Create(ctx context.Context, company *Company) (*Comp
 	}
 	defer r.rollBackOnError(tx)
 
+	if appErr := r.createInTx(ctx, tx, company); appErr != nil {
+		return nil, appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.l.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return company, nil
+}
+
+// createInTx inserts the company and its company_created event within tx.
+func (r *companyRepository) createInTx(ctx context.Context, tx *sql.Tx, company *Company) common.AppError {
 	var exists bool
-	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM companies WHERE LOWER(name) = LOWER($1))", company.Name).Scan(&exists)
+	err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM companies WHERE LOWER(name) = LOWER($1) AND deleted_at IS NULL)", company.Name).Scan(&exists)
 	if err != nil {
 		r.l.Error("failed to check company existence", "err", err)
-		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
 
 	if exists {
-		return nil, common.NewConflictError("company with this name already exists")
+		return common.NewConflictError("company with this name already exists")
 	}
 
+	if company.ParentID != nil {
+		if appErr := r.checkParentExists(ctx, tx, *company.ParentID); appErr != nil {
+			return appErr
+		}
+	}
+
+	company.Status = InitialCompanyStatus(company.Registered)
+
 	query := `
-        INSERT INTO companies (id, name, description, amount_of_employees, registered, type)
-        VALUES ($1, $2, $3, $4, $5, $6)
-        RETURNING created_at, updated_at
+        INSERT INTO companies (id, name, description, amount_of_employees, registered, status, type, owner_id, parent_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING version, created_at, updated_at
     `
 
 	err = tx.QueryRowContext(ctx, query,
-		company.ID, company.Name, company.Description, company.AmountOfEmployees,
-		company.Registered, company.Type).Scan(&company.CreatedAt, &company.UpdatedAt)
+		company.ID, company.Name, company.Description, company.AmountOfEmployees, company.Registered,
+		company.Status, company.Type, company.OwnerID, company.ParentID).Scan(&company.Version, &company.CreatedAt, &company.UpdatedAt)
 
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
-			return nil, common.NewConflictError("company with this name already exists")
+		switch pgErrorCode(err) {
+		case pgCodeUniqueViolation:
+			return common.NewConflictError("company with this name already exists")
+		case pgCodeForeignKeyViolation:
+			// The type was removed since the caller validated it.
+			return common.NewBadRequestError(errUnknownCompanyType)
 		}
 
 		r.l.Error("failed to create company", "err", err)
-		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
-	}
-
-	if err := r.storeCompanyEvent(ctx, "company_created", company); err != nil {
-		r.l.Error("failed to store company_created event", "err", err)
-	}
-
-	if err = tx.Commit(); err != nil {
-		r.l.Error(common.ErrTxCommit, "err", err)
-		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
 
-	return company, nil
+	return r.storeCompanyEvent(ctx, tx, EventCompanyCreated, company.ID, company)
 }
 
 // FindByID retrieves a company by its UUID.
@@ -97,19 +139,12 @@ func (r *companyRepository) Create(ctx context.Context, company *Company) (*Comp
 // Returns a not found error if the company is found but has been deleted.
 func (r *companyRepository) FindByID(ctx context.Context, id uuid.UUID) (*Company, common.AppError) {
 	query := `
-    SELECT id, name, description, amount_of_employees, registered, type, created_at, updated_at, deleted_at
+    SELECT ` + companyColumns + `
     FROM companies
     WHERE id = $1
     `
 
-	var company Company
-	var description sql.NullString
-	var deletedAt sql.NullTime
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&company.ID, &company.Name, &description, &company.AmountOfEmployees,
-		&company.Registered, &company.Type, &company.CreatedAt, &company.UpdatedAt, &deletedAt)
-
+	company, err := scanCompany(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, common.NewNotFoundError("company not found")
@@ -118,21 +153,20 @@ func (r *companyRepository) FindByID(ctx context.Context, id uuid.UUID) (*Compan
 		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
 
-	if deletedAt.Valid {
+	if company.DeletedAt != nil {
 		return nil, common.NewNotFoundError("company has been deleted, please contact support")
 	}
 
-	if description.Valid {
-		company.Description = &description.String
-	}
-
-	return &company, nil
+	return company, nil
 }
 
-// Update modifies an existing company record.
+// Update modifies an existing, non-deleted company record.
+// When expectedVersion is set, the update only applies to that version of the company and
+// a PreconditionFailedError is returned otherwise.
 // Uses a serializable transaction to ensure data consistency.
-// Produces company_updated event.
-func (r *companyRepository) Update(ctx context.Context, id uuid.UUID, updates map[string]any) (*Company, common.AppError) {
+// Produces company_updated event in the same transaction, carrying the updated company and the
+// before and after values of every changed field, read from the row locked by the transaction.
+func (r *companyRepository) Update(ctx context.Context, id uuid.UUID, updates map[string]any, expectedVersion *int) (*Company, common.AppError) {
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
 		r.l.Error(common.ErrTXBegin, "err", err)
@@ -140,130 +174,1152 @@ func (r *companyRepository) Update(ctx context.Context, id uuid.UUID, updates ma
 	}
 	defer r.rollBackOnError(tx)
 
+	company, appErr := r.updateInTx(ctx, tx, id, updates, expectedVersion)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.l.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return company, nil
+}
+
+// updateInTx applies updates to the company and stores its company_updated event within tx.
+func (r *companyRepository) updateInTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, updates map[string]any, expectedVersion *int) (*Company, common.AppError) {
+	before, err := scanCompany(tx.QueryRowContext(ctx,
+		`SELECT `+companyColumns+` FROM companies WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.NewNotFoundError("company not found")
+		}
+		r.l.Error("failed to get company for update", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
 	setClause, args := buildUpdateQuery(updates)
 	args = append(args, id)
+	whereClause := "id = $" + fmt.Sprintf("%d", len(args))
+
+	if expectedVersion != nil {
+		args = append(args, *expectedVersion)
+		whereClause += " AND version = $" + fmt.Sprintf("%d", len(args))
+	}
 
 	query := `
         UPDATE companies
         SET ` + setClause + `
-        WHERE id = $` + fmt.Sprintf("%d", len(args)) + `
-        RETURNING id, name, description, amount_of_employees, registered, type, created_at, updated_at
+        WHERE ` + whereClause + `
+        RETURNING ` + companyColumns + `
     `
 
-	var company Company
-	var description sql.NullString
-
-	err = tx.QueryRowContext(ctx, query, args...).Scan(
-		&company.ID, &company.Name, &description, &company.AmountOfEmployees,
-		&company.Registered, &company.Type, &company.CreatedAt, &company.UpdatedAt)
-
+	company, err := scanCompany(tx.QueryRowContext(ctx, query, args...))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, common.NewNotFoundError("company not found")
+			// The row is locked and known to exist, so only the version can have excluded it.
+			return nil, common.NewPreconditionFailedError(errVersionMismatch)
+		}
+		switch pgErrorCode(err) {
+		case pgCodeUniqueViolation:
+			return nil, common.NewConflictError("company with this name already exists")
+		case pgCodeForeignKeyViolation:
+			return nil, common.NewBadRequestError(errUnknownCompanyType)
 		}
 		r.l.Error("failed to update company", "err", err)
 		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
 
-	if description.Valid {
-		company.Description = &description.String
+	event := struct {
+		*Company
+		Changes map[string]FieldChange `json:"changes"`
+	}{Company: company, Changes: DiffCompanies(before, company)}
+
+	if appErr := r.storeCompanyEvent(ctx, tx, EventCompanyUpdated, company.ID, event); appErr != nil {
+		return nil, appErr
+	}
+
+	return company, nil
+}
+
+// Delete performs a soft delete on a company record by setting its deleted_at timestamp.
+// Returns NotFoundError if the company doesn't exist or is already deleted.
+// When expectedVersion is set, only that version of the company is deleted and
+// a PreconditionFailedError is returned otherwise.
+// A company with active subsidiaries is only deleted with cascade, which deletes all of its active
+// descendants along with it; without cascade a ConflictError is returned.
+// Produces a company_deleted event per deleted company in the same transaction.
+func (r *companyRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersion *int, cascade bool) common.AppError {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		r.l.Error(common.ErrTXBegin, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
+	defer r.rollBackOnError(tx)
 
-	if err := r.storeCompanyEvent(ctx, "company_updated", &company); err != nil {
-		r.l.Error("failed to store company_updated event", "err", err)
+	if _, appErr := r.deleteInTx(ctx, tx, id, expectedVersion, cascade); appErr != nil {
+		return appErr
 	}
 
 	if err = tx.Commit(); err != nil {
 		r.l.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// deleteInTx soft deletes the company, and its active descendants with cascade, and stores their
+// company_deleted events within tx.
+func (r *companyRepository) deleteInTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, expectedVersion *int, cascade bool) (*Company, common.AppError) {
+	query := `
+        UPDATE companies
+        SET deleted_at = NOW()
+        WHERE id = $1 AND deleted_at IS NULL AND ($2::integer IS NULL OR version = $2)
+        RETURNING ` + companyColumns + `
+    `
+
+	company, err := scanCompany(tx.QueryRowContext(ctx, query, id, expectedVersion))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, r.deleteMissError(ctx, tx, id, expectedVersion)
+		}
+
+		r.l.Error("failed to delete company", "err", err)
 		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
 
-	return &company, nil
+	if appErr := r.storeCompanyEvent(ctx, tx, EventCompanyDeleted, company.ID, company); appErr != nil {
+		return nil, appErr
+	}
+
+	if appErr := r.deleteSubsidiaries(ctx, tx, id, cascade); appErr != nil {
+		return nil, appErr
+	}
+
+	return company, nil
 }
 
-// Delete performs a soft delete on a company record by setting its deleted_at timestamp.
-// Returns NotFoundError if the company doesn't exist or is already deleted.
-// Produces company_deleted event.
-func (r *companyRepository) Delete(ctx context.Context, id uuid.UUID) common.AppError {
+// deleteSubsidiaries soft deletes the active descendants of a company being deleted and stores their
+// company_deleted events. Without cascade, it returns a ConflictError if there are any.
+func (r *companyRepository) deleteSubsidiaries(ctx context.Context, tx *sql.Tx, id uuid.UUID, cascade bool) common.AppError {
+	if !cascade {
+		var exists bool
+		err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM companies WHERE parent_id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+		if err != nil {
+			r.l.Error("failed to check company subsidiaries", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		if exists {
+			return common.NewConflictError("company has active subsidiaries, delete them first or delete with cascade")
+		}
+
+		return nil
+	}
+
+	query := `
+        WITH RECURSIVE descendants AS (
+            SELECT id FROM companies WHERE parent_id = $1 AND deleted_at IS NULL
+            UNION
+            SELECT companies.id FROM companies JOIN descendants ON companies.parent_id = descendants.id
+            WHERE companies.deleted_at IS NULL
+        )
+        UPDATE companies
+        SET deleted_at = NOW()
+        WHERE id IN (SELECT id FROM descendants)
+        RETURNING ` + companyColumns + `
+    `
+
+	rows, err := tx.QueryContext(ctx, query, id)
+	if err != nil {
+		r.l.Error("failed to delete company subsidiaries", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	var deleted []*Company
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			r.l.Error("failed to scan deleted subsidiary", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+		deleted = append(deleted, company)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.l.Error("failed to iterate deleted subsidiaries", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	for _, company := range deleted {
+		if appErr := r.storeCompanyEvent(ctx, tx, EventCompanyDeleted, company.ID, company); appErr != nil {
+			return appErr
+		}
+	}
+
+	return nil
+}
+
+// Batch applies the operations in order within one serializable transaction, producing the
+// same event per affected company as Create, Update and Delete.
+// When atomic is set, the first failing operation aborts the batch and nothing is applied.
+// Otherwise every operation runs within its own savepoint, so a failure only undoes itself.
+// Results are returned in the order of ops; an AppError is returned only when the
+// transaction itself fails, in which case nothing is applied.
+func (r *companyRepository) Batch(ctx context.Context, ops []CompanyOperation, atomic bool) ([]CompanyOperationResult, common.AppError) {
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
 		r.l.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer r.rollBackOnError(tx)
+
+	results := make([]CompanyOperationResult, len(ops))
+
+	for i, op := range ops {
+		if !atomic {
+			if _, err = tx.ExecContext(ctx, "SAVEPOINT batch_operation"); err != nil {
+				r.l.Error("failed to create savepoint", "err", err)
+				return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+			}
+		}
+
+		company, appErr := r.applyOperation(ctx, tx, op)
+		results[i] = CompanyOperationResult{Company: company, Err: appErr}
+
+		switch {
+		case appErr != nil && atomic:
+			return results, nil
+		case appErr != nil:
+			_, err = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_operation")
+		case !atomic:
+			_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_operation")
+		}
+
+		if err != nil {
+			r.l.Error("failed to end savepoint", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.l.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return results, nil
+}
+
+// applyOperation runs a single batch operation within tx.
+func (r *companyRepository) applyOperation(ctx context.Context, tx *sql.Tx, op CompanyOperation) (*Company, common.AppError) {
+	switch op.Type {
+	case CompanyOperationCreate:
+		if appErr := r.createInTx(ctx, tx, op.Company); appErr != nil {
+			return nil, appErr
+		}
+		return op.Company, nil
+	case CompanyOperationUpdate:
+		return r.updateInTx(ctx, tx, op.ID, op.Updates, op.ExpectedVersion)
+	case CompanyOperationDelete:
+		return r.deleteInTx(ctx, tx, op.ID, op.ExpectedVersion, op.Cascade)
+	default:
+		return nil, common.NewBadRequestError(fmt.Sprintf("unknown operation %q", op.Type))
+	}
+}
+
+// deleteMissError explains why Delete matched no row: a version mismatch when the company
+// still exists, NotFoundError otherwise.
+func (r *companyRepository) deleteMissError(ctx context.Context, tx *sql.Tx, id uuid.UUID, expectedVersion *int) common.AppError {
+	if expectedVersion == nil {
+		return common.NewNotFoundError("company not found or already deleted")
+	}
+
+	var exists bool
+	err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM companies WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+	if err != nil {
+		r.l.Error("failed to check company existence", "err", err)
 		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
+
+	if exists {
+		return common.NewPreconditionFailedError(errVersionMismatch)
+	}
+
+	return common.NewNotFoundError("company not found or already deleted")
+}
+
+// TransferOwnership hands a non-deleted company over to another user.
+// Returns NotFoundError if the company doesn't exist or is deleted, or if the new owner doesn't exist.
+// Produces company_ownership_transferred event carrying the previous owner, in the same transaction.
+func (r *companyRepository) TransferOwnership(ctx context.Context, id uuid.UUID, newOwnerID uuid.UUID) (*Company, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		r.l.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
 	defer r.rollBackOnError(tx)
 
+	var previousOwnerID uuid.NullUUID
+	err = tx.QueryRowContext(ctx, `SELECT owner_id FROM companies WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).
+		Scan(&previousOwnerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.NewNotFoundError("company not found")
+		}
+		r.l.Error("failed to get company owner", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
 	query := `
         UPDATE companies
-        SET deleted_at = NOW()
-        WHERE id = $1 AND deleted_at IS NULL
-        RETURNING id, name, description, amount_of_employees, registered, type, created_at, updated_at, deleted_at
+        SET owner_id = $1
+        WHERE id = $2
+        RETURNING ` + companyColumns + `
     `
 
-	var company Company
-	var description, deletedAt sql.NullString
+	company, err := scanCompany(tx.QueryRowContext(ctx, query, newOwnerID, id))
+	if err != nil {
+		if pgErrorCode(err) == pgCodeForeignKeyViolation {
+			return nil, common.NewNotFoundError("new owner not found")
+		}
+		r.l.Error("failed to transfer company ownership", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	event := struct {
+		*Company
+		PreviousOwnerID *uuid.UUID `json:"previousOwnerId"`
+	}{Company: company}
+
+	if previousOwnerID.Valid {
+		event.PreviousOwnerID = &previousOwnerID.UUID
+	}
+
+	if appErr := r.storeCompanyEvent(ctx, tx, EventCompanyOwnershipTransferred, company.ID, event); appErr != nil {
+		return nil, appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.l.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return company, nil
+}
 
-	err = tx.QueryRowContext(ctx, query, id).Scan(
-		&company.ID, &company.Name, &description, &company.AmountOfEmployees,
-		&company.Registered, &company.Type, &company.CreatedAt, &company.UpdatedAt, &deletedAt)
+// Transition moves an active company to a new lifecycle status, keeping its registered field in line
+// with it. Only the transitions of companyTransitions are allowed; others return a ConflictError.
+// Returns NotFoundError if the company doesn't exist or is deleted. When expectedVersion is set,
+// only that version of the company is changed and a PreconditionFailedError is returned otherwise.
+// Produces the event of the transition, carrying the previous status and the reason, in the same transaction.
+func (r *companyRepository) Transition(ctx context.Context, id uuid.UUID, status string, reason string,
+	expectedVersion *int) (*Company, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		r.l.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer r.rollBackOnError(tx)
 
+	before, err := scanCompany(tx.QueryRowContext(ctx,
+		`SELECT `+companyColumns+` FROM companies WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return common.NewNotFoundError("company not found or already deleted")
+			return nil, common.NewNotFoundError("company not found")
 		}
+		r.l.Error("failed to get company for transition", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
 
-		r.l.Error("failed to delete company", "err", err)
-		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	if expectedVersion != nil && before.Version != *expectedVersion {
+		return nil, common.NewPreconditionFailedError(errVersionMismatch)
 	}
 
-	if description.Valid {
-		company.Description = &description.String
+	eventType, err := TransitionEvent(before.Status, status)
+	if err != nil {
+		return nil, common.NewConflictError(err.Error())
 	}
 
-	if deletedAt.Valid {
-		parsedTime, _ := time.Parse(time.RFC3339, deletedAt.String)
-		company.DeletedAt = &parsedTime
+	query := `
+        UPDATE companies
+        SET status = $1, registered = $2
+        WHERE id = $3
+        RETURNING ` + companyColumns + `
+    `
+
+	company, err := scanCompany(tx.QueryRowContext(ctx, query, status, IsRegisteredStatus(status), id))
+	if err != nil {
+		r.l.Error("failed to change company status", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
 
-	if err := r.storeCompanyEvent(ctx, "company_deleted", &company); err != nil {
-		r.l.Error("Failed to store company_deleted event", "err", err)
+	event := struct {
+		*Company
+		PreviousStatus string `json:"previousStatus"`
+		Reason         string `json:"reason"`
+	}{Company: company, PreviousStatus: before.Status, Reason: reason}
+
+	if appErr := r.storeCompanyEvent(ctx, tx, eventType, company.ID, event); appErr != nil {
+		return nil, appErr
 	}
 
 	if err = tx.Commit(); err != nil {
 		r.l.Error(common.ErrTxCommit, "err", err)
-		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
 
-	return nil
+	return company, nil
 }
 
-// buildUpdateQuery constructs the SET clause and arguments for an UPDATE query.
-// Example:
-//
-//	updates := map[string]any{"name": "New Corp", "registered": true}
-//	setClause, args := buildUpdateQuery(updates)
-//	// setClause: "name = $1, registered = $2"
-//	// args: []any{"New Corp", true}
-func buildUpdateQuery(updates map[string]any) (string, []any) {
-	setClauses := make([]string, 0, len(updates))
-	args := make([]any, 0, len(updates))
-	i := 1
+// Restore clears deleted_at of a soft-deleted company.
+// Returns NotFoundError if the company doesn't exist, and ConflictError if it isn't deleted
+// or its name has since been taken by another active company.
+// Produces company_restored event in the same transaction.
+func (r *companyRepository) Restore(ctx context.Context, id uuid.UUID) (*Company, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		r.l.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer r.rollBackOnError(tx)
 
-	for key, value := range updates {
-		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", key, i))
-		args = append(args, value)
-		i++
+	var name string
+	var deletedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, `SELECT name, deleted_at FROM companies WHERE id = $1 FOR UPDATE`, id).
+		Scan(&name, &deletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.NewNotFoundError("company not found")
+		}
+		r.l.Error("failed to get company for restore", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
 
-	return strings.Join(setClauses, ", "), args
+	if !deletedAt.Valid {
+		return nil, common.NewConflictError("company is not deleted")
+	}
+
+	var nameTaken bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM companies WHERE LOWER(name) = LOWER($1) AND deleted_at IS NULL AND id <> $2)",
+		name, id).Scan(&nameTaken)
+	if err != nil {
+		r.l.Error("failed to check company existence", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if nameTaken {
+		return nil, common.NewConflictError("company name has been taken by another company")
+	}
+
+	query := `
+        UPDATE companies
+        SET deleted_at = NULL
+        WHERE id = $1
+        RETURNING ` + companyColumns + `
+    `
+
+	company, err := scanCompany(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if pgErrorCode(err) == pgCodeUniqueViolation {
+			return nil, common.NewConflictError("company name has been taken by another company")
+		}
+		r.l.Error("failed to restore company", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if appErr := r.storeCompanyEvent(ctx, tx, EventCompanyRestored, company.ID, company); appErr != nil {
+		return nil, appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.l.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return company, nil
+}
+
+// PurgeDeleted permanently removes up to limit companies soft-deleted before deletedBefore,
+// oldest first, and returns how many were removed.
+// Produces a company_purged event per removed company in the same transaction. Subsidiaries of a
+// purged company that are not purged themselves become top-level companies, each with a
+// company_parent_changed event.
+// Rows are locked with FOR UPDATE SKIP LOCKED, so concurrent purges never collide on a company.
+func (r *companyRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		r.l.Error(common.ErrTXBegin, "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer r.rollBackOnError(tx)
+
+	ids, appErr := r.lockPurgeable(ctx, tx, deletedBefore, limit)
+	if appErr != nil {
+		return 0, appErr
+	}
+
+	purging := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		purging[id] = true
+	}
+
+	for _, id := range ids {
+		if appErr := r.detachSubsidiaries(ctx, tx, id, purging); appErr != nil {
+			return 0, appErr
+		}
+	}
+
+	for _, id := range ids {
+		company, err := scanCompany(tx.QueryRowContext(ctx, `DELETE FROM companies WHERE id = $1 RETURNING `+companyColumns, id))
+		if err != nil {
+			r.l.Error("failed to purge deleted company", "err", err)
+			return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		if appErr := r.storeCompanyEvent(ctx, tx, EventCompanyPurged, company.ID, company); appErr != nil {
+			return 0, appErr
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.l.Error(common.ErrTxCommit, "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return len(ids), nil
+}
+
+// lockPurgeable locks up to limit companies soft-deleted before deletedBefore, oldest first,
+// skipping those locked by a concurrent purge, and returns their IDs.
+func (r *companyRepository) lockPurgeable(ctx context.Context, tx *sql.Tx, deletedBefore time.Time, limit int) ([]uuid.UUID, common.AppError) {
+	query := `
+        SELECT id FROM companies
+        WHERE deleted_at < $1
+        ORDER BY deleted_at
+        LIMIT $2
+        FOR UPDATE SKIP LOCKED
+    `
+
+	rows, err := tx.QueryContext(ctx, query, deletedBefore, limit)
+	if err != nil {
+		r.l.Error("failed to select purgeable companies", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			r.l.Error("failed to scan purgeable company", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.l.Error("failed to iterate purgeable companies", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return ids, nil
+}
+
+// detachSubsidiaries clears the parent of the subsidiaries of a company about to be purged, which the
+// foreign key would otherwise do silently, and stores a company_parent_changed event for each one
+// that isn't purged as well.
+func (r *companyRepository) detachSubsidiaries(ctx context.Context, tx *sql.Tx, parentID uuid.UUID, purging map[uuid.UUID]bool) common.AppError {
+	query := `
+        UPDATE companies
+        SET parent_id = NULL
+        WHERE parent_id = $1
+        RETURNING ` + companyColumns + `
+    `
+
+	rows, err := tx.QueryContext(ctx, query, parentID)
+	if err != nil {
+		r.l.Error("failed to detach subsidiaries of purged company", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	var detached []*Company
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			r.l.Error("failed to scan detached subsidiary", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		if !purging[company.ID] {
+			detached = append(detached, company)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		r.l.Error("failed to iterate detached subsidiaries", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	for _, company := range detached {
+		if appErr := r.storeParentChangedEvent(ctx, tx, company, &parentID); appErr != nil {
+			return appErr
+		}
+	}
+
+	return nil
+}
+
+// List returns a page of non-deleted companies matching the filter, ordered by id.
+// Uses keyset pagination on id, so the partial idx_companies_not_deleted index serves both
+// the deleted_at predicate and the ordering; type and employee filters can use
+// idx_companies_type and idx_companies_employees.
+// Fetches one extra row to report whether a further page exists.
+func (r *companyRepository) List(ctx context.Context, filter CompanyFilter) (*CompanyPage, common.AppError) {
+	whereClause, args := buildListQuery(filter)
+	args = append(args, filter.Limit+1)
+
+	query := `
+        SELECT ` + companyColumns + `
+        FROM companies
+        WHERE ` + whereClause + `
+        ORDER BY id
+        LIMIT $` + fmt.Sprintf("%d", len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.l.Error("failed to list companies", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	companies := make([]Company, 0, filter.Limit+1)
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			r.l.Error("failed to scan company", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		companies = append(companies, *company)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.l.Error("failed to iterate companies", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	page := &CompanyPage{Companies: companies}
+	if len(companies) > filter.Limit {
+		page.Companies = companies[:filter.Limit]
+		page.HasMore = true
+	}
+
+	return page, nil
+}
+
+// Export hands every company matching the filter to fn, in id order; the filter's Limit is ignored.
+// Rows are read through a server-side cursor, exportFetchSize at a time, within a read-only
+// repeatable read transaction, so the export is a consistent snapshot however long it streams.
+// An error returned by fn stops the export.
+func (r *companyRepository) Export(ctx context.Context, filter CompanyFilter, fn func(*Company) error) common.AppError {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		r.l.Error(common.ErrTXBegin, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer r.rollBackOnError(tx)
+
+	whereClause, args := buildListQuery(filter)
+
+	query := `
+        DECLARE company_export NO SCROLL CURSOR FOR
+        SELECT ` + companyColumns + `
+        FROM companies
+        WHERE ` + whereClause + `
+        ORDER BY id
+    `
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		r.l.Error("failed to declare company export cursor", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	for {
+		fetched, appErr := r.fetchExportBatch(ctx, tx, fn)
+		if appErr != nil {
+			return appErr
+		}
+
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.l.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// fetchExportBatch hands the next exportFetchSize companies of the export cursor to fn and
+// returns how many were fetched.
+func (r *companyRepository) fetchExportBatch(ctx context.Context, tx *sql.Tx, fn func(*Company) error) (int, common.AppError) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("FETCH %d FROM company_export", exportFetchSize))
+	if err != nil {
+		r.l.Error("failed to fetch from company export cursor", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	fetched := 0
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			r.l.Error("failed to scan company", "err", err)
+			return fetched, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		if err = fn(company); err != nil {
+			return fetched, common.NewInternalServerError("company export aborted", err)
+		}
+		fetched++
+	}
+
+	if err := rows.Err(); err != nil {
+		r.l.Error("failed to iterate company export cursor", "err", err)
+		return fetched, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return fetched, nil
+}
+
+// maxHierarchyDepth bounds the walks of the company hierarchy.
+const maxHierarchyDepth = 100
+
+// SetParent makes a non-deleted company a subsidiary of another non-deleted company, or a top-level
+// company when parentID is nil. Returns NotFoundError if either company doesn't exist or is deleted,
+// and ConflictError if the parent is the company itself or one of its descendants.
+// Produces company_parent_changed event carrying the previous parent, in the same transaction;
+// setting the current parent again changes nothing.
+func (r *companyRepository) SetParent(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) (*Company, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		r.l.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer r.rollBackOnError(tx)
+
+	before, err := scanCompany(tx.QueryRowContext(ctx,
+		`SELECT `+companyColumns+` FROM companies WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.NewNotFoundError("company not found")
+		}
+		r.l.Error("failed to get company for parent change", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if equalUUIDPtr(before.ParentID, parentID) {
+		return before, nil
+	}
+
+	if parentID != nil {
+		if appErr := r.checkParentExists(ctx, tx, *parentID); appErr != nil {
+			return nil, appErr
+		}
+
+		if appErr := r.checkNoCycle(ctx, tx, id, *parentID); appErr != nil {
+			return nil, appErr
+		}
+	}
+
+	query := `
+        UPDATE companies
+        SET parent_id = $1
+        WHERE id = $2
+        RETURNING ` + companyColumns + `
+    `
+
+	company, err := scanCompany(tx.QueryRowContext(ctx, query, parentID, id))
+	if err != nil {
+		r.l.Error("failed to set company parent", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if appErr := r.storeParentChangedEvent(ctx, tx, company, before.ParentID); appErr != nil {
+		return nil, appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.l.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return company, nil
+}
+
+// storeParentChangedEvent stores a company_parent_changed event carrying the company and its previous parent.
+func (r *companyRepository) storeParentChangedEvent(ctx context.Context, tx *sql.Tx, company *Company, previousParentID *uuid.UUID) common.AppError {
+	event := struct {
+		*Company
+		PreviousParentID *uuid.UUID `json:"previousParentId"`
+	}{Company: company, PreviousParentID: previousParentID}
+
+	return r.storeCompanyEvent(ctx, tx, EventCompanyParentChanged, company.ID, event)
+}
+
+// checkParentExists returns a NotFoundError unless the parent company exists and isn't deleted.
+func (r *companyRepository) checkParentExists(ctx context.Context, tx *sql.Tx, parentID uuid.UUID) common.AppError {
+	var exists bool
+	err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM companies WHERE id = $1 AND deleted_at IS NULL)", parentID).Scan(&exists)
+	if err != nil {
+		r.l.Error("failed to check parent company existence", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if !exists {
+		return common.NewNotFoundError("parent company not found")
+	}
+
+	return nil
+}
+
+// checkNoCycle returns a ConflictError if the company is parentID itself or one of its ancestors,
+// in which case making parentID its parent would close a cycle.
+func (r *companyRepository) checkNoCycle(ctx context.Context, tx *sql.Tx, id uuid.UUID, parentID uuid.UUID) common.AppError {
+	query := `
+        WITH RECURSIVE ancestors AS (
+            SELECT id, parent_id FROM companies WHERE id = $1
+            UNION
+            SELECT companies.id, companies.parent_id FROM companies JOIN ancestors ON companies.id = ancestors.parent_id
+        )
+        SELECT EXISTS(SELECT 1 FROM ancestors WHERE id = $2)
+    `
+
+	var cycle bool
+	if err := tx.QueryRowContext(ctx, query, parentID, id).Scan(&cycle); err != nil {
+		r.l.Error("failed to check company hierarchy for cycles", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if cycle {
+		return common.NewConflictError("a company can't be a subsidiary of itself or of its own subsidiaries")
+	}
+
+	return nil
+}
+
+// Subsidiaries returns the active direct subsidiaries of a company or, when recursive, all of its
+// active descendants, ordered by depth then id. Subsidiaries of deleted companies are not walked.
+func (r *companyRepository) Subsidiaries(ctx context.Context, id uuid.UUID, recursive bool) ([]RelatedCompany, common.AppError) {
+	maxDepth := 1
+	if recursive {
+		maxDepth = maxHierarchyDepth
+	}
+
+	query := `
+        WITH RECURSIVE subsidiaries AS (
+            SELECT id, 1 AS depth FROM companies WHERE parent_id = $1 AND deleted_at IS NULL
+            UNION
+            SELECT companies.id, subsidiaries.depth + 1
+            FROM companies JOIN subsidiaries ON companies.parent_id = subsidiaries.id
+            WHERE companies.deleted_at IS NULL AND subsidiaries.depth < $2
+        )
+        SELECT ` + companyColumns + `, subsidiaries.depth
+        FROM companies JOIN subsidiaries USING (id)
+        ORDER BY subsidiaries.depth, id
+    `
+
+	return r.queryRelatedCompanies(ctx, query, id, maxDepth)
+}
+
+// Ancestors returns the parent of a company, its parent's parent and so on up to the top of the
+// group, nearest first. Deleted ancestors are included, with their deletedAt set.
+func (r *companyRepository) Ancestors(ctx context.Context, id uuid.UUID) ([]RelatedCompany, common.AppError) {
+	query := `
+        WITH RECURSIVE ancestors AS (
+            SELECT parent_id AS id, 1 AS depth FROM companies WHERE id = $1 AND parent_id IS NOT NULL
+            UNION
+            SELECT companies.parent_id, ancestors.depth + 1
+            FROM companies JOIN ancestors ON companies.id = ancestors.id
+            WHERE companies.parent_id IS NOT NULL AND ancestors.depth < $2
+        )
+        SELECT ` + companyColumns + `, ancestors.depth
+        FROM companies JOIN ancestors USING (id)
+        ORDER BY ancestors.depth
+    `
+
+	return r.queryRelatedCompanies(ctx, query, id, maxHierarchyDepth)
+}
+
+// queryRelatedCompanies runs a hierarchy query selecting companyColumns followed by the depth.
+func (r *companyRepository) queryRelatedCompanies(ctx context.Context, query string, args ...any) ([]RelatedCompany, common.AppError) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.l.Error("failed to query company hierarchy", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	related := make([]RelatedCompany, 0)
+	for rows.Next() {
+		var depth int
+		company, err := scanCompany(extraColumns{row: rows, extra: []any{&depth}})
+		if err != nil {
+			r.l.Error("failed to scan related company", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		related = append(related, RelatedCompany{Company: *company, Depth: depth})
+	}
+
+	if err := rows.Err(); err != nil {
+		r.l.Error("failed to iterate company hierarchy", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return related, nil
+}
+
+// TakenNames returns the given names already used by a non-deleted company, compared
+// case-insensitively like the idx_companies_name_lower unique index, which serves the lookup.
+func (r *companyRepository) TakenNames(ctx context.Context, names []string) ([]string, common.AppError) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	values := make([]string, len(names))
+	args := make([]any, len(names))
+	for i, name := range names {
+		values[i] = fmt.Sprintf("($%d::text)", i+1)
+		args[i] = name
+	}
+
+	query := `
+        SELECT candidate.name
+        FROM (VALUES ` + strings.Join(values, ", ") + `) AS candidate(name)
+        WHERE EXISTS (
+            SELECT 1 FROM companies
+            WHERE LOWER(companies.name) = LOWER(candidate.name) AND companies.deleted_at IS NULL
+        )
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.l.Error("failed to check taken company names", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	var taken []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			r.l.Error("failed to scan taken company name", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+		taken = append(taken, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.l.Error("failed to iterate taken company names", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return taken, nil
+}
+
+// searchHeadlineOptions configures the description snippets of Search.
+const searchHeadlineOptions = `StartSel="` + SearchMatchStart + `", StopSel="` + SearchMatchStop + `", MaxFragments=2, MaxWords=25, MinWords=8, FragmentDelimiter=" … "`
+
+// Search returns the non-deleted companies whose description matches the query as a web search
+// style full-text query, or whose name is similar to or contains the query, best matches first.
+// Uses idx_companies_description_fts and idx_companies_name_trgm.
+func (r *companyRepository) Search(ctx context.Context, query string, limit int) ([]CompanySearchResult, common.AppError) {
+	sqlQuery := `
+        WITH search AS (SELECT websearch_to_tsquery('english', $1) AS query)
+        SELECT ` + companyColumns + `,
+            similarity(name, $1) + ts_rank_cd(to_tsvector('english', COALESCE(description, '')), search.query) AS score,
+            CASE WHEN to_tsvector('english', COALESCE(description, '')) @@ search.query
+                THEN ts_headline('english', description, search.query, $3)
+                ELSE ''
+            END AS snippet
+        FROM companies, search
+        WHERE deleted_at IS NULL
+            AND (to_tsvector('english', COALESCE(description, '')) @@ search.query OR name % $1 OR name ILIKE $2)
+        ORDER BY score DESC, id
+        LIMIT $4
+    `
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, query, "%"+escapeLikePattern(query)+"%", searchHeadlineOptions, limit)
+	if err != nil {
+		r.l.Error("failed to search companies", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	results := make([]CompanySearchResult, 0, limit)
+	for rows.Next() {
+		var result CompanySearchResult
+		company, err := scanCompany(extraColumns{row: rows, extra: []any{&result.Score, &result.DescriptionSnippet}})
+		if err != nil {
+			r.l.Error("failed to scan company search result", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		result.Company = *company
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.l.Error("failed to iterate company search results", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return results, nil
+}
+
+// escapeLikePattern escapes the LIKE wildcards of s, so that it only matches literally.
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// buildListQuery constructs the WHERE clause and arguments for listing companies.
+// Example:
+//
+//	filter := CompanyFilter{Type: &corp, MinEmployees: &ten}
+//	whereClause, args := buildListQuery(filter)
+//	// whereClause: "deleted_at IS NULL AND type = $1 AND amount_of_employees >= $2"
+//	// args: []any{"Corporations", 10}
+func buildListQuery(filter CompanyFilter) (string, []any) {
+	var conditions []string
+	var args []any
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
+	addCondition := func(format string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.Type != nil {
+		addCondition("type = $%d", *filter.Type)
+	}
+
+	if filter.Registered != nil {
+		addCondition("registered = $%d", *filter.Registered)
+	}
+
+	if filter.Status != nil {
+		addCondition("status = $%d", *filter.Status)
+	}
+
+	if filter.MinEmployees != nil {
+		addCondition("amount_of_employees >= $%d", *filter.MinEmployees)
+	}
+
+	if filter.MaxEmployees != nil {
+		addCondition("amount_of_employees <= $%d", *filter.MaxEmployees)
+	}
+
+	if filter.CreatedAfter != nil {
+		addCondition("created_at >= $%d", *filter.CreatedAfter)
+	}
+
+	if filter.CreatedBefore != nil {
+		addCondition("created_at < $%d", *filter.CreatedBefore)
+	}
+
+	if filter.OwnerID != nil {
+		addCondition("owner_id = $%d", *filter.OwnerID)
+	}
+
+	if filter.AfterID != nil {
+		addCondition("id > $%d", *filter.AfterID)
+	}
+
+	if len(conditions) == 0 {
+		return "TRUE", args
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// buildUpdateQuery constructs the SET clause and arguments for an UPDATE query.
+// Example:
+//
+//	updates := map[string]any{"name": "New Corp", "registered": true}
+//	setClause, args := buildUpdateQuery(updates)
+//	// setClause: "name = $1, registered = $2"
+//	// args: []any{"New Corp", true}
+func buildUpdateQuery(updates map[string]any) (string, []any) {
+	setClauses := make([]string, 0, len(updates))
+	args := make([]any, 0, len(updates))
+	i := 1
+
+	for key, value := range updates {
+		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", key, i))
+		args = append(args, value)
+		i++
+	}
+
+	return strings.Join(setClauses, ", "), args
+}
+
+// scanCompany reads a row selected with companyColumns into a Company.
+func scanCompany(row rowScanner) (*Company, error) {
+	var company Company
+	var description sql.NullString
+	var ownerID, parentID uuid.NullUUID
+	var deletedAt sql.NullTime
+
+	err := row.Scan(&company.ID, &company.Name, &description, &company.AmountOfEmployees,
+		&company.Registered, &company.Status, &company.Type, &ownerID, &parentID, &company.Version, &company.CreatedAt, &company.UpdatedAt, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if description.Valid {
+		company.Description = &description.String
+	}
+
+	if ownerID.Valid {
+		company.OwnerID = &ownerID.UUID
+	}
+
+	if parentID.Valid {
+		company.ParentID = &parentID.UUID
+	}
+
+	if deletedAt.Valid {
+		company.DeletedAt = &deletedAt.Time
+	}
+
+	return &company, nil
 }
 
-func (r *companyRepository) storeCompanyEvent(ctx context.Context, eventType string, company *Company) error {
-	eventData, err := json.Marshal(company)
+// storeCompanyEvent records a company event in the same transaction as the mutation.
+// The payload is usually the company itself, possibly extended with event specific fields,
+// and is wrapped in a CloudEvent envelope.
+// A failure is returned to the caller so the whole transaction is rolled back,
+// keeping the events outbox consistent with the companies table.
+func (r *companyRepository) storeCompanyEvent(ctx context.Context, tx *sql.Tx, eventType string, companyID uuid.UUID, payload any) common.AppError {
+	event, err := NewCloudEvent(ctx, eventType, companyID, payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal company data: %w", err)
+		r.l.Error("failed to build company event", "eventType", eventType, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedEvent, err)
 	}
 
-	return r.eventRepository.StoreEvent(ctx, eventType, eventData)
+	return r.eventRepository.StoreEvent(ctx, tx, event)
 }
 
 // rollBackOnError attempts to roll back a transaction if an error occurred.
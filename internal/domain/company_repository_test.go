@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBuildListQuery(t *testing.T) {
+	corp := "Corporations"
+	ten := 10
+
+	tests := []struct {
+		name       string
+		filter     CompanyFilter
+		wantClause string
+		wantArgs   int
+	}{
+		{"No filter", CompanyFilter{}, "deleted_at IS NULL", 0},
+		{"Filters", CompanyFilter{Type: &corp, MinEmployees: &ten}, "deleted_at IS NULL AND type = $1 AND amount_of_employees >= $2", 2},
+		{"Including deleted", CompanyFilter{IncludeDeleted: true}, "TRUE", 0},
+		{"Including deleted with filters", CompanyFilter{Type: &corp, IncludeDeleted: true}, "type = $1", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause, args := buildListQuery(tt.filter)
+			if clause != tt.wantClause {
+				t.Errorf("buildListQuery() clause = %v, want %v", clause, tt.wantClause)
+			}
+
+			if len(args) != tt.wantArgs {
+				t.Errorf("buildListQuery() args = %v, want %d args", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestEscapeLikePattern(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"acme", "acme"},
+		{"100%", `100\%`},
+		{"a_b", `a\_b`},
+		{`c:\dir`, `c:\\dir`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLikePattern(tt.input); got != tt.want {
+			t.Errorf("escapeLikePattern(%q) got = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCompanyRepositoryCreateStatus(t *testing.T) {
+	db := openTestDB(t)
+	repo := newTestCompanyRepository(db)
+
+	tests := []struct {
+		name       string
+		registered bool
+		wantStatus string
+	}{
+		{"Unregistered", false, CompanyStatusDraft},
+		{"Registered", true, CompanyStatusRegistered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			company := newTestCompany(t, db, tt.registered)
+			if _, appErr := repo.Create(context.Background(), company); appErr != nil {
+				t.Fatalf("Create() unexpected error = %v", appErr)
+			}
+
+			stored, appErr := repo.FindByID(context.Background(), company.ID)
+			if appErr != nil {
+				t.Fatalf("FindByID() unexpected error = %v", appErr)
+			}
+
+			if stored.Registered != tt.registered || stored.Status != tt.wantStatus {
+				t.Errorf("Create() stored registered = %v, status = %v, want %v, %v",
+					stored.Registered, stored.Status, tt.registered, tt.wantStatus)
+			}
+
+			if _, appErr := repo.Transition(context.Background(), company.ID, CompanyStatusPendingRegistration, "filed", nil); tt.registered != (appErr != nil) {
+				t.Errorf("Transition() to pending_registration error = %v", appErr)
+			}
+		})
+	}
+}
+
+func TestCompanyRepositoryPurgeDetachesSubsidiaries(t *testing.T) {
+	db := openTestDB(t)
+	repo := newTestCompanyRepository(db)
+	ctx := context.Background()
+
+	parent := newTestCompany(t, db, true)
+	if _, appErr := repo.Create(ctx, parent); appErr != nil {
+		t.Fatalf("Create() unexpected error = %v", appErr)
+	}
+
+	child := newTestCompany(t, db, true)
+	child.ParentID = &parent.ID
+	if _, appErr := repo.Create(ctx, child); appErr != nil {
+		t.Fatalf("Create() unexpected error = %v", appErr)
+	}
+
+	// Deleted long before anything else in the database, so only this company is purged.
+	deletedAt := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := db.Exec("UPDATE companies SET deleted_at = $1 WHERE id = $2", deletedAt, parent.ID); err != nil {
+		t.Fatalf("failed to delete parent company: %v", err)
+	}
+
+	purged, appErr := repo.PurgeDeleted(ctx, deletedAt.Add(time.Second), 10)
+	if appErr != nil || purged != 1 {
+		t.Fatalf("PurgeDeleted() got = %v, %v, want 1 purged", purged, appErr)
+	}
+
+	stored, appErr := repo.FindByID(ctx, child.ID)
+	if appErr != nil {
+		t.Fatalf("FindByID() unexpected error = %v", appErr)
+	}
+
+	if stored.ParentID != nil {
+		t.Errorf("PurgeDeleted() left subsidiary parent = %v, want none", stored.ParentID)
+	}
+
+	var previousParentID string
+	err := db.QueryRow(`SELECT data->'data'->>'previousParentId' FROM events WHERE aggregate_id = $1 AND event_type = $2`,
+		child.ID, EventCompanyParentChanged).Scan(&previousParentID)
+	if err != nil {
+		t.Fatalf("PurgeDeleted() stored no %s event for the subsidiary: %v", EventCompanyParentChanged, err)
+	}
+
+	if previousParentID != parent.ID.String() {
+		t.Errorf("PurgeDeleted() event previousParentId = %v, want %v", previousParentID, parent.ID)
+	}
+}
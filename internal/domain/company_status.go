@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Lifecycle statuses of a company.
+const (
+	CompanyStatusDraft               = "draft"
+	CompanyStatusPendingRegistration = "pending_registration"
+	CompanyStatusRegistered          = "registered"
+	CompanyStatusSuspended           = "suspended"
+	CompanyStatusDissolved           = "dissolved"
+)
+
+// companyTransitions maps every status to the statuses a company may move to from it, along with
+// the event type produced by each transition. Dissolved is final.
+var companyTransitions = map[string]map[string]string{
+	CompanyStatusDraft: {
+		CompanyStatusPendingRegistration: EventCompanyRegistrationRequested,
+	},
+	CompanyStatusPendingRegistration: {
+		CompanyStatusRegistered: EventCompanyRegistered,
+		CompanyStatusDraft:      EventCompanyRegistrationWithdrawn,
+	},
+	CompanyStatusRegistered: {
+		CompanyStatusSuspended: EventCompanySuspended,
+		CompanyStatusDissolved: EventCompanyDissolved,
+	},
+	CompanyStatusSuspended: {
+		CompanyStatusRegistered: EventCompanyReinstated,
+		CompanyStatusDissolved:  EventCompanyDissolved,
+	},
+	CompanyStatusDissolved: {},
+}
+
+// InitialCompanyStatus returns the status of a new company: registered for companies created as
+// registered, draft otherwise.
+func InitialCompanyStatus(registered bool) string {
+	if registered {
+		return CompanyStatusRegistered
+	}
+
+	return CompanyStatusDraft
+}
+
+// IsRegisteredStatus reports whether companies in status count as registered, which the registered
+// field of a company follows. Suspended companies remain registered; dissolved ones no longer are.
+func IsRegisteredStatus(status string) bool {
+	return status == CompanyStatusRegistered || status == CompanyStatusSuspended
+}
+
+// TransitionEvent returns the event type of moving a company from one status to another,
+// or an error naming the allowed statuses when the transition isn't allowed.
+func TransitionEvent(from, to string) (string, error) {
+	allowed, ok := companyTransitions[from]
+	if !ok {
+		return "", fmt.Errorf("unknown company status %q", from)
+	}
+
+	if eventType, ok := allowed[to]; ok {
+		return eventType, nil
+	}
+
+	if len(allowed) == 0 {
+		return "", fmt.Errorf("a %s company can't change status", from)
+	}
+
+	next := make([]string, 0, len(allowed))
+	for status := range allowed {
+		next = append(next, status)
+	}
+	sort.Strings(next)
+
+	return "", fmt.Errorf("a %s company can't become %s, only %s", from, to, strings.Join(next, " or "))
+}
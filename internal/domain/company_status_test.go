@@ -0,0 +1,54 @@
+package domain
+
+import "testing"
+
+func TestTransitionEvent(t *testing.T) {
+	tests := []struct {
+		from      string
+		to        string
+		wantEvent string
+		wantErr   string
+	}{
+		{CompanyStatusDraft, CompanyStatusPendingRegistration, EventCompanyRegistrationRequested, ""},
+		{CompanyStatusPendingRegistration, CompanyStatusRegistered, EventCompanyRegistered, ""},
+		{CompanyStatusPendingRegistration, CompanyStatusDraft, EventCompanyRegistrationWithdrawn, ""},
+		{CompanyStatusRegistered, CompanyStatusSuspended, EventCompanySuspended, ""},
+		{CompanyStatusRegistered, CompanyStatusDissolved, EventCompanyDissolved, ""},
+		{CompanyStatusSuspended, CompanyStatusRegistered, EventCompanyReinstated, ""},
+		{CompanyStatusSuspended, CompanyStatusDissolved, EventCompanyDissolved, ""},
+		{CompanyStatusDraft, CompanyStatusRegistered, "", "a draft company can't become registered, only pending_registration"},
+		{CompanyStatusRegistered, CompanyStatusRegistered, "", "a registered company can't become registered, only dissolved or suspended"},
+		{CompanyStatusDissolved, CompanyStatusRegistered, "", "a dissolved company can't change status"},
+		{"archived", CompanyStatusDraft, "", `unknown company status "archived"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			eventType, err := TransitionEvent(tt.from, tt.to)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("TransitionEvent() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("TransitionEvent() unexpected error = %v", err)
+			}
+
+			if eventType != tt.wantEvent {
+				t.Errorf("TransitionEvent() got = %v, want %v", eventType, tt.wantEvent)
+			}
+		})
+	}
+}
+
+func TestTransitionEventsAreRegistered(t *testing.T) {
+	for from, allowed := range companyTransitions {
+		for to, eventType := range allowed {
+			if _, ok := eventSchemaVersions[eventType]; !ok {
+				t.Errorf("transition from %s to %s produces unregistered event type %s", from, to, eventType)
+			}
+		}
+	}
+}
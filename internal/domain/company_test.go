@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+func TestDiffCompanies(t *testing.T) {
+	oldDescription, newDescription := "Innovative solutions", "Sustainable solutions"
+	before := Company{
+		ID:                uuid.New(),
+		Name:              "TechCorp",
+		Description:       &oldDescription,
+		AmountOfEmployees: 100,
+		Registered:        true,
+		Type:              "Corporations",
+	}
+
+	tests := []struct {
+		name   string
+		update func(c *Company)
+		want   map[string]FieldChange
+	}{
+		{"No changes", func(c *Company) {}, map[string]FieldChange{}},
+		{"Registered flipped", func(c *Company) { c.Registered = false },
+			map[string]FieldChange{"registered": {From: true, To: false}}},
+		{"Name and employees", func(c *Company) { c.Name = "TechCorp2"; c.AmountOfEmployees = 150 },
+			map[string]FieldChange{
+				"name":              {From: "TechCorp", To: "TechCorp2"},
+				"amountOfEmployees": {From: 100, To: 150},
+			}},
+		{"Description changed", func(c *Company) { c.Description = &newDescription },
+			map[string]FieldChange{"description": {From: &oldDescription, To: &newDescription}}},
+		{"Description cleared", func(c *Company) { c.Description = nil },
+			map[string]FieldChange{"description": {From: &oldDescription, To: (*string)(nil)}}},
+		{"Same description, different pointer", func(c *Company) { d := oldDescription; c.Description = &d },
+			map[string]FieldChange{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := before
+			tt.update(&after)
+
+			if got := DiffCompanies(&before, &after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffCompanies() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameVariants(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantFirst string
+	}{
+		{"Acme", "Acme Group"},
+		{"Globex Holdings", "Globex Ho Group"},
+		{"  Initech  ", "Initech Group"},
+		{"Éclair Bakery", "Éclair Ba Group"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variants := NameVariants(tt.name)
+			if len(variants) == 0 || variants[0] != tt.wantFirst {
+				t.Fatalf("NameVariants() got = %v, want first %v", variants, tt.wantFirst)
+			}
+
+			seen := make(map[string]bool)
+			for _, variant := range variants {
+				if n := utf8.RuneCountInString(variant); n > CompanyNameMaxLength {
+					t.Errorf("NameVariants() variant %q has %d characters, want at most %d", variant, n, CompanyNameMaxLength)
+				}
+
+				key := strings.ToLower(variant)
+				if seen[key] || strings.EqualFold(variant, tt.name) {
+					t.Errorf("NameVariants() variant %q repeats a name", variant)
+				}
+				seen[key] = true
+			}
+		})
+	}
+}
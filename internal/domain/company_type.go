@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// CompanyType is an entry of the company_types reference table that every company's type must name.
+// Names are unique ignoring case and can't be changed; a type in use by any company, deleted ones
+// included, can't be removed.
+type CompanyType struct {
+	Name        string     `json:"name"`
+	Description *string    `json:"description,omitempty"`
+	CreatedAt   *time.Time `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ashtishad/xm/common"
+)
+
+// cachedCompanyTypeRepository keeps the names of the company types in process memory, so validating
+// the type of every created or updated company does not hit the database.
+// The names are reloaded at most every ttl, which bounds how long a type added or removed through
+// another server instance goes unnoticed. Changes made through this instance reload them right away.
+// Everything but Exists reads from the wrapped repository.
+type cachedCompanyTypeRepository struct {
+	CompanyTypeRepository
+	ttl time.Duration
+
+	mu         sync.Mutex
+	names      map[string]bool
+	loadedAt   time.Time
+	generation int
+}
+
+// NewCachedCompanyTypeRepository wraps repo with an in-process cache of the type names that lives for ttl.
+func NewCachedCompanyTypeRepository(repo CompanyTypeRepository, ttl time.Duration) CompanyTypeRepository {
+	return &cachedCompanyTypeRepository{
+		CompanyTypeRepository: repo,
+		ttl:                   ttl,
+	}
+}
+
+func (c *cachedCompanyTypeRepository) Create(ctx context.Context, companyType *CompanyType) (*CompanyType, common.AppError) {
+	created, appErr := c.CompanyTypeRepository.Create(ctx, companyType)
+	if appErr == nil {
+		c.invalidate()
+	}
+
+	return created, appErr
+}
+
+func (c *cachedCompanyTypeRepository) Delete(ctx context.Context, name string) common.AppError {
+	appErr := c.CompanyTypeRepository.Delete(ctx, name)
+	if appErr == nil {
+		c.invalidate()
+	}
+
+	return appErr
+}
+
+func (c *cachedCompanyTypeRepository) Exists(ctx context.Context, name string) (bool, common.AppError) {
+	now := time.Now()
+
+	c.mu.Lock()
+	names, fresh := c.names, c.names != nil && now.Sub(c.loadedAt) < c.ttl
+	generation := c.generation
+	c.mu.Unlock()
+
+	if fresh {
+		return names[name], nil
+	}
+
+	companyTypes, appErr := c.CompanyTypeRepository.List(ctx)
+	if appErr != nil {
+		return false, appErr
+	}
+
+	names = make(map[string]bool, len(companyTypes))
+	for _, companyType := range companyTypes {
+		names[companyType.Name] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Names loaded while a type was being added or removed may predate the change; don't keep them.
+	if c.generation == generation {
+		c.names, c.loadedAt = names, now
+	}
+
+	return names[name], nil
+}
+
+// invalidate makes the next Exists call reload the type names.
+func (c *cachedCompanyTypeRepository) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.names = nil
+	c.generation++
+}
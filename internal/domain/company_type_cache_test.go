@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xm/common"
+)
+
+// countingCompanyTypeRepository is an in-memory CompanyTypeRepository that counts List lookups.
+type countingCompanyTypeRepository struct {
+	CompanyTypeRepository
+	names   []string
+	lookups int
+}
+
+func (r *countingCompanyTypeRepository) List(_ context.Context) ([]CompanyType, common.AppError) {
+	r.lookups++
+	companyTypes := make([]CompanyType, 0, len(r.names))
+	for _, name := range r.names {
+		companyTypes = append(companyTypes, CompanyType{Name: name})
+	}
+	return companyTypes, nil
+}
+
+func (r *countingCompanyTypeRepository) Create(_ context.Context, companyType *CompanyType) (*CompanyType, common.AppError) {
+	r.names = append(r.names, companyType.Name)
+	return companyType, nil
+}
+
+func (r *countingCompanyTypeRepository) Delete(_ context.Context, name string) common.AppError {
+	for i, n := range r.names {
+		if n == name {
+			r.names = append(r.names[:i], r.names[i+1:]...)
+			return nil
+		}
+	}
+	return common.NewNotFoundError(errCompanyTypeNotFound)
+}
+
+func TestCachedCompanyTypeRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingCompanyTypeRepository{names: []string{"Corporations", "NonProfit"}}
+	cache := NewCachedCompanyTypeRepository(repo, time.Minute)
+
+	for _, name := range []string{"Corporations", "NonProfit", "Corporations"} {
+		if exists, _ := cache.Exists(ctx, name); !exists {
+			t.Fatalf("Exists(%q) got = false, want true", name)
+		}
+	}
+	if exists, _ := cache.Exists(ctx, "corporations"); exists {
+		t.Errorf("Exists() got = true for a name differing in case")
+	}
+	if repo.lookups != 1 {
+		t.Errorf("Exists() hit the repository %d times, want 1", repo.lookups)
+	}
+
+	if _, appErr := cache.Create(ctx, &CompanyType{Name: "Partnership"}); appErr != nil {
+		t.Fatalf("Create() unexpected error = %v", appErr)
+	}
+	if exists, _ := cache.Exists(ctx, "Partnership"); !exists {
+		t.Errorf("Exists() got = false right after Create")
+	}
+
+	if appErr := cache.Delete(ctx, "NonProfit"); appErr != nil {
+		t.Fatalf("Delete() unexpected error = %v", appErr)
+	}
+	if exists, _ := cache.Exists(ctx, "NonProfit"); exists {
+		t.Errorf("Exists() got = true right after Delete")
+	}
+
+	if appErr := cache.Delete(ctx, "Cooperative"); appErr == nil {
+		t.Fatalf("Delete() of a missing type got no error")
+	}
+	if lookups := repo.lookups; lookups != 3 {
+		t.Errorf("Exists() hit the repository %d times, want 3", lookups)
+	}
+}
@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/ashtishad/xm/common"
+)
+
+// CompanyTypeRepository manages the company types companies may be created with.
+type CompanyTypeRepository interface {
+	List(ctx context.Context) ([]CompanyType, common.AppError)
+	FindByName(ctx context.Context, name string) (*CompanyType, common.AppError)
+	Create(ctx context.Context, companyType *CompanyType) (*CompanyType, common.AppError)
+	Update(ctx context.Context, name string, description *string) (*CompanyType, common.AppError)
+	Delete(ctx context.Context, name string) common.AppError
+	Exists(ctx context.Context, name string) (bool, common.AppError)
+}
+
+// companyTypeColumns lists the columns read by scanCompanyType, in order.
+const companyTypeColumns = `name, description, created_at, updated_at`
+
+const errCompanyTypeNotFound = "company type not found"
+
+type companyTypeRepository struct {
+	db *sql.DB
+	l  *slog.Logger
+}
+
+// NewCompanyTypeRepository creates a new instance of CompanyTypeRepository.
+func NewCompanyTypeRepository(db *sql.DB, logger *slog.Logger) CompanyTypeRepository {
+	return &companyTypeRepository{
+		db: db,
+		l:  logger,
+	}
+}
+
+// List returns all company types ordered by name.
+func (r *companyTypeRepository) List(ctx context.Context) ([]CompanyType, common.AppError) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+companyTypeColumns+` FROM company_types ORDER BY name`)
+	if err != nil {
+		r.l.Error("failed to list company types", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	companyTypes := make([]CompanyType, 0)
+	for rows.Next() {
+		companyType, err := scanCompanyType(rows)
+		if err != nil {
+			r.l.Error("failed to scan company type", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+		companyTypes = append(companyTypes, *companyType)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.l.Error("failed to iterate company types", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return companyTypes, nil
+}
+
+// FindByName retrieves a company type by its exact name.
+// Returns NotFoundError if there is no such type.
+func (r *companyTypeRepository) FindByName(ctx context.Context, name string) (*CompanyType, common.AppError) {
+	query := `SELECT ` + companyTypeColumns + ` FROM company_types WHERE name = $1`
+
+	companyType, err := scanCompanyType(r.db.QueryRowContext(ctx, query, name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.NewNotFoundError(errCompanyTypeNotFound)
+		}
+		r.l.Error("failed to get company type", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return companyType, nil
+}
+
+// Create inserts a new company type.
+// Returns ConflictError if a type with the same name, ignoring case, already exists.
+func (r *companyTypeRepository) Create(ctx context.Context, companyType *CompanyType) (*CompanyType, common.AppError) {
+	query := `
+        INSERT INTO company_types (name, description)
+        VALUES ($1, $2)
+        RETURNING ` + companyTypeColumns + `
+    `
+
+	created, err := scanCompanyType(r.db.QueryRowContext(ctx, query, companyType.Name, companyType.Description))
+	if err != nil {
+		if pgErrorCode(err) == pgCodeUniqueViolation {
+			return nil, common.NewConflictError("company type with this name already exists")
+		}
+		r.l.Error("failed to create company type", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return created, nil
+}
+
+// Update replaces the description of a company type; a nil description clears it.
+// Returns NotFoundError if there is no such type.
+func (r *companyTypeRepository) Update(ctx context.Context, name string, description *string) (*CompanyType, common.AppError) {
+	query := `
+        UPDATE company_types
+        SET description = $2
+        WHERE name = $1
+        RETURNING ` + companyTypeColumns + `
+    `
+
+	updated, err := scanCompanyType(r.db.QueryRowContext(ctx, query, name, description))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.NewNotFoundError(errCompanyTypeNotFound)
+		}
+		r.l.Error("failed to update company type", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return updated, nil
+}
+
+// Delete removes a company type.
+// Returns NotFoundError if there is no such type, and ConflictError while any company,
+// including soft-deleted ones, is of that type.
+func (r *companyTypeRepository) Delete(ctx context.Context, name string) common.AppError {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM company_types WHERE name = $1`, name)
+	if err != nil {
+		if pgErrorCode(err) == pgCodeForeignKeyViolation {
+			return common.NewConflictError("company type is in use by companies")
+		}
+		r.l.Error("failed to delete company type", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		r.l.Error("failed to get affected rows", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if affected == 0 {
+		return common.NewNotFoundError(errCompanyTypeNotFound)
+	}
+
+	return nil
+}
+
+// Exists reports whether a company type with the exact given name exists.
+func (r *companyTypeRepository) Exists(ctx context.Context, name string) (bool, common.AppError) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM company_types WHERE name = $1)", name).Scan(&exists)
+	if err != nil {
+		r.l.Error("failed to check company type existence", "err", err)
+		return false, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return exists, nil
+}
+
+// scanCompanyType reads a row selected with companyTypeColumns into a CompanyType.
+func scanCompanyType(row rowScanner) (*CompanyType, error) {
+	var companyType CompanyType
+	var description sql.NullString
+
+	if err := row.Scan(&companyType.Name, &description, &companyType.CreatedAt, &companyType.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	companyType.Description = nullStringPtr(description)
+
+	return &companyType, nil
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CompanyVersion is the state of a company as of one of its versions. RecordedAt is when the version
+// was written, and the version remains the state of the company until the next one is recorded.
+type CompanyVersion struct {
+	Company
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// CompanyVersionFilter selects the versions of a single company, oldest first.
+// AfterVersion is the keyset cursor: when set, only later versions are returned.
+type CompanyVersionFilter struct {
+	CompanyID    uuid.UUID
+	AfterVersion *int
+	Limit        int
+}
+
+// CompanyVersionPage is a single page of company versions. HasMore reports whether another page follows.
+type CompanyVersionPage struct {
+	Versions []CompanyVersion
+	HasMore  bool
+}
+
+// DiffCompanyVersions returns the fields that differ between two versions of a company, keyed by their
+// JSON name. On top of the fields of DiffCompanies, it compares the status, ownership, parent and
+// deletion time, which change outside of updates. Timestamps of the changes themselves are not compared.
+func DiffCompanyVersions(from, to *Company) map[string]FieldChange {
+	changes := DiffCompanies(from, to)
+
+	if from.Status != to.Status {
+		changes["status"] = FieldChange{From: from.Status, To: to.Status}
+	}
+
+	if !equalUUIDPtr(from.OwnerID, to.OwnerID) {
+		changes["ownerId"] = FieldChange{From: from.OwnerID, To: to.OwnerID}
+	}
+
+	if !equalUUIDPtr(from.ParentID, to.ParentID) {
+		changes["parentId"] = FieldChange{From: from.ParentID, To: to.ParentID}
+	}
+
+	if !equalTimePtr(from.DeletedAt, to.DeletedAt) {
+		changes["deletedAt"] = FieldChange{From: from.DeletedAt, To: to.DeletedAt}
+	}
+
+	return changes
+}
+
+func equalTimePtr(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xm/common"
+	"github.com/google/uuid"
+)
+
+// CompanyVersionRepository reads the history of companies. Versions are recorded by the database
+// whenever a company is inserted or updated, so the history covers every change whatever its origin,
+// and outlives the company when it is purged.
+type CompanyVersionRepository interface {
+	List(ctx context.Context, filter CompanyVersionFilter) (*CompanyVersionPage, common.AppError)
+	FindByVersion(ctx context.Context, companyID uuid.UUID, version int) (*CompanyVersion, common.AppError)
+	FindAsOf(ctx context.Context, companyID uuid.UUID, asOf time.Time) (*CompanyVersion, common.AppError)
+}
+
+// companyVersionColumns lists the columns read by scanCompanyVersion, in order. The leading ones
+// line up with companyColumns, so scanCompany reads the company of the version.
+const companyVersionColumns = `company_id, name, description, amount_of_employees, registered, status, type, owner_id, parent_id, version, created_at, updated_at, deleted_at, recorded_at`
+
+const errCompanyVersionNotFound = "company version not found"
+
+type companyVersionRepository struct {
+	db *sql.DB
+	l  *slog.Logger
+}
+
+// NewCompanyVersionRepository creates a new instance of CompanyVersionRepository.
+func NewCompanyVersionRepository(db *sql.DB, logger *slog.Logger) CompanyVersionRepository {
+	return &companyVersionRepository{
+		db: db,
+		l:  logger,
+	}
+}
+
+// List returns a page of the versions of a company in version order.
+// Fetches one extra row to determine whether more pages are available.
+func (r *companyVersionRepository) List(ctx context.Context, filter CompanyVersionFilter) (*CompanyVersionPage, common.AppError) {
+	args := []any{filter.CompanyID}
+	whereClause := "company_id = $1"
+
+	if filter.AfterVersion != nil {
+		args = append(args, *filter.AfterVersion)
+		whereClause += fmt.Sprintf(" AND version > $%d", len(args))
+	}
+
+	args = append(args, filter.Limit+1)
+
+	query := `
+        SELECT ` + companyVersionColumns + `
+        FROM company_versions
+        WHERE ` + whereClause + `
+        ORDER BY version
+        LIMIT $` + fmt.Sprintf("%d", len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.l.Error("failed to list company versions", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	versions := make([]CompanyVersion, 0, filter.Limit+1)
+	for rows.Next() {
+		version, err := scanCompanyVersion(rows)
+		if err != nil {
+			r.l.Error("failed to scan company version", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+		versions = append(versions, *version)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.l.Error("failed to iterate company versions", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	page := &CompanyVersionPage{Versions: versions}
+	if len(versions) > filter.Limit {
+		page.Versions = versions[:filter.Limit]
+		page.HasMore = true
+	}
+
+	return page, nil
+}
+
+// FindByVersion retrieves a single version of a company, including versions where it is deleted.
+// Returns NotFoundError if the company has no such version.
+func (r *companyVersionRepository) FindByVersion(ctx context.Context, companyID uuid.UUID, version int) (*CompanyVersion, common.AppError) {
+	query := `SELECT ` + companyVersionColumns + ` FROM company_versions WHERE company_id = $1 AND version = $2`
+
+	companyVersion, err := scanCompanyVersion(r.db.QueryRowContext(ctx, query, companyID, version))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.NewNotFoundError(errCompanyVersionNotFound)
+		}
+		r.l.Error("failed to get company version", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return companyVersion, nil
+}
+
+// FindAsOf retrieves the version of a company that was current at asOf: the latest one recorded
+// at or before it. Like FindByID, it returns NotFoundError if the company didn't exist yet
+// or was deleted at that time.
+func (r *companyVersionRepository) FindAsOf(ctx context.Context, companyID uuid.UUID, asOf time.Time) (*CompanyVersion, common.AppError) {
+	query := `
+        SELECT ` + companyVersionColumns + `
+        FROM company_versions
+        WHERE company_id = $1 AND recorded_at <= $2
+        ORDER BY recorded_at DESC, version DESC
+        LIMIT 1
+    `
+
+	companyVersion, err := scanCompanyVersion(r.db.QueryRowContext(ctx, query, companyID, asOf))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.NewNotFoundError("company not found as of that time")
+		}
+		r.l.Error("failed to get company as of a point in time", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if companyVersion.DeletedAt != nil {
+		return nil, common.NewNotFoundError("company was deleted as of that time")
+	}
+
+	return companyVersion, nil
+}
+
+// scanCompanyVersion reads a row selected with companyVersionColumns into a CompanyVersion.
+func scanCompanyVersion(row rowScanner) (*CompanyVersion, error) {
+	var recordedAt time.Time
+
+	company, err := scanCompany(extraColumns{row: row, extra: []any{&recordedAt}})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompanyVersion{Company: *company, RecordedAt: recordedAt}, nil
+}
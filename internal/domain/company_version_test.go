@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDiffCompanyVersions(t *testing.T) {
+	ownerID, newOwnerID, parentID := uuid.New(), uuid.New(), uuid.New()
+	deletedAt := time.Date(2024, 9, 27, 16, 55, 36, 0, time.UTC)
+	from := Company{
+		ID:                uuid.New(),
+		Name:              "TechCorp",
+		AmountOfEmployees: 100,
+		Registered:        true,
+		Status:            CompanyStatusRegistered,
+		Type:              "Corporations",
+		OwnerID:           &ownerID,
+		Version:           1,
+	}
+
+	tests := []struct {
+		name   string
+		update func(c *Company)
+		want   map[string]FieldChange
+	}{
+		{"Version only", func(c *Company) { c.Version = 2 }, map[string]FieldChange{}},
+		{"Suspended", func(c *Company) { c.Status = CompanyStatusSuspended },
+			map[string]FieldChange{"status": {From: CompanyStatusRegistered, To: CompanyStatusSuspended}}},
+		{"Dissolved", func(c *Company) { c.Status = CompanyStatusDissolved; c.Registered = false },
+			map[string]FieldChange{
+				"status":     {From: CompanyStatusRegistered, To: CompanyStatusDissolved},
+				"registered": {From: true, To: false},
+			}},
+		{"Owner transferred", func(c *Company) { c.OwnerID = &newOwnerID },
+			map[string]FieldChange{"ownerId": {From: &ownerID, To: &newOwnerID}}},
+		{"Owner removed", func(c *Company) { c.OwnerID = nil },
+			map[string]FieldChange{"ownerId": {From: &ownerID, To: (*uuid.UUID)(nil)}}},
+		{"Parent set", func(c *Company) { c.ParentID = &parentID },
+			map[string]FieldChange{"parentId": {From: (*uuid.UUID)(nil), To: &parentID}}},
+		{"Deleted", func(c *Company) { c.DeletedAt = &deletedAt },
+			map[string]FieldChange{"deletedAt": {From: (*time.Time)(nil), To: &deletedAt}}},
+		{"Renamed", func(c *Company) { c.Name = "TechCorp2" },
+			map[string]FieldChange{"name": {From: "TechCorp", To: "TechCorp2"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to := from
+			tt.update(&to)
+
+			if got := DiffCompanyVersions(&from, &to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffCompanyVersions() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"database/sql"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testDBConnEnv names the environment variable holding the connection string of a migrated
+// database for the repository tests, which are skipped when it isn't set.
+const testDBConnEnv = "TEST_DB_CONN_STRING"
+
+// openTestDB connects to the test database, skipping the test when none is configured.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	connString := os.Getenv(testDBConnEnv)
+	if connString == "" {
+		t.Skipf("%s is not set", testDBConnEnv)
+	}
+
+	db, err := sql.Open("pgx", connString)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Ping(); err != nil {
+		t.Fatalf("failed to reach test database: %v", err)
+	}
+
+	return db
+}
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newTestCompanyRepository returns a CompanyRepository on db.
+func newTestCompanyRepository(db *sql.DB) CompanyRepository {
+	return NewCompanyRepository(db, newTestLogger(), NewEventRepository(db, newTestLogger()))
+}
+
+// newTestCompany returns an unsaved company with a unique name, removed from db when the test ends.
+func newTestCompany(t *testing.T, db *sql.DB, registered bool) *Company {
+	t.Helper()
+
+	id := uuid.New()
+	t.Cleanup(func() {
+		for _, query := range []string{
+			"DELETE FROM events WHERE aggregate_id = $1",
+			"DELETE FROM company_versions WHERE company_id = $1",
+			"DELETE FROM companies WHERE id = $1",
+		} {
+			if _, err := db.Exec(query, id); err != nil {
+				t.Errorf("failed to clean up test company: %v", err)
+			}
+		}
+	})
+
+	return &Company{
+		ID:                id,
+		Name:              "T" + id.String()[:12],
+		AmountOfEmployees: 5,
+		Registered:        registered,
+		Type:              "Corporations",
+	}
+}
+
+// newTestUser stores a user with a unique email, removed from db with its tokens when the test ends.
+func newTestUser(t *testing.T, db *sql.DB) int {
+	t.Helper()
+
+	var id int
+	email := uuid.NewString() + "@example.com"
+	if err := db.QueryRow(`INSERT INTO users (email, name) VALUES ($1, $2) RETURNING id`, email, "Test").Scan(&id); err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if _, err := db.Exec("DELETE FROM users WHERE id = $1", id); err != nil {
+			t.Errorf("failed to clean up test user: %v", err)
+		}
+	})
+
+	return id
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/ashtishad/xm/common"
@@ -12,15 +14,42 @@ import (
 )
 
 type Event struct {
-	ID        uuid.UUID       `json:"id"`
-	EventType string          `json:"eventType"`
-	Data      json.RawMessage `json:"data"`
-	CreatedAt time.Time       `json:"createdAt"`
-	UpdatedAt time.Time       `json:"updatedAt"`
+	ID          uuid.UUID       `json:"id"`
+	AggregateID uuid.UUID       `json:"aggregateId"`
+	EventType   string          `json:"eventType"`
+	Data        json.RawMessage `json:"data" swaggertype:"object"`
+	Attempts    int             `json:"attempts"`
+	PublishedAt *time.Time      `json:"publishedAt,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
 }
 
+// EventFilter selects the events of a single aggregate, oldest first.
+// AfterCreatedAt and AfterID form the keyset cursor: when set, only events strictly after
+// that position in (created_at, id) order are returned.
+type EventFilter struct {
+	AggregateID    uuid.UUID
+	EventType      *string
+	AfterCreatedAt *time.Time
+	AfterID        *uuid.UUID
+	Limit          int
+}
+
+// EventPage is a single page of events. HasMore reports whether another page follows.
+type EventPage struct {
+	Events  []Event
+	HasMore bool
+}
+
+// EventRepository persists domain events to the events table, which acts as a transactional outbox.
+// Events are written with the caller's transaction so they commit or roll back together with
+// the mutation that produced them.
 type EventRepository interface {
-	StoreEvent(ctx context.Context, eventType string, data json.RawMessage) error
+	StoreEvent(ctx context.Context, tx *sql.Tx, event *CloudEvent) common.AppError
+	ListByAggregate(ctx context.Context, filter EventFilter) (*EventPage, common.AppError)
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]Event, common.AppError)
+	MarkPublished(ctx context.Context, id uuid.UUID) common.AppError
+	MarkFailed(ctx context.Context, id uuid.UUID, deliveryErr error, retryIn time.Duration) common.AppError
 }
 
 type eventRepository struct {
@@ -35,15 +64,171 @@ func NewEventRepository(db *sql.DB, l *slog.Logger) EventRepository {
 	}
 }
 
-func (r *eventRepository) StoreEvent(ctx context.Context, eventType string, data json.RawMessage) error {
+// StoreEvent inserts an event within the given transaction. The whole envelope is stored as the
+// event data; its ID, type and aggregate ID are also kept in their own columns for querying.
+// The caller owns the transaction; a returned error should cause it to be rolled back.
+func (r *eventRepository) StoreEvent(ctx context.Context, tx *sql.Tx, event *CloudEvent) common.AppError {
+	data, err := json.Marshal(event)
+	if err != nil {
+		r.l.Error("failed to marshal event", "eventType", event.Type, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedEvent, err)
+	}
+
 	query := `
-        INSERT INTO events (event_type, data)
-        VALUES ($1, $2)
+        INSERT INTO events (id, event_type, aggregate_id, data, created_at)
+        VALUES ($1, $2, $3, $4, $5)
     `
-	if _, err := r.db.ExecContext(ctx, query, eventType, data); err != nil {
+	if _, err := tx.ExecContext(ctx, query, event.ID, event.Type, event.AggregateID, data, event.Time); err != nil {
 		r.l.Error("failed to store event", "err", err)
 		return common.NewInternalServerError(common.ErrUnexpectedEvent, err)
 	}
 
 	return nil
 }
+
+// ListByAggregate returns a page of events of a single aggregate in (created_at, id) order.
+// Fetches one extra row to determine whether more pages are available.
+func (r *eventRepository) ListByAggregate(ctx context.Context, filter EventFilter) (*EventPage, common.AppError) {
+	conditions := []string{"aggregate_id = $1"}
+	args := []any{filter.AggregateID}
+
+	if filter.EventType != nil {
+		args = append(args, *filter.EventType)
+		conditions = append(conditions, fmt.Sprintf("event_type = $%d", len(args)))
+	}
+
+	if filter.AfterCreatedAt != nil && filter.AfterID != nil {
+		args = append(args, *filter.AfterCreatedAt, *filter.AfterID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) > ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	args = append(args, filter.Limit+1)
+
+	query := `
+        SELECT id, aggregate_id, event_type, data, attempts, published_at, created_at, updated_at
+        FROM events
+        WHERE ` + strings.Join(conditions, " AND ") + `
+        ORDER BY created_at, id
+        LIMIT $` + fmt.Sprintf("%d", len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.l.Error("failed to list events", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	events := make([]Event, 0, filter.Limit+1)
+	for rows.Next() {
+		var event Event
+		var publishedAt sql.NullTime
+		if err := rows.Scan(&event.ID, &event.AggregateID, &event.EventType, &event.Data, &event.Attempts,
+			&publishedAt, &event.CreatedAt, &event.UpdatedAt); err != nil {
+			r.l.Error("failed to scan event", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		if publishedAt.Valid {
+			event.PublishedAt = &publishedAt.Time
+		}
+
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.l.Error("failed to iterate events", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	page := &EventPage{Events: events}
+	if len(events) > filter.Limit {
+		page.Events = events[:filter.Limit]
+		page.HasMore = true
+	}
+
+	return page, nil
+}
+
+// ClaimPending leases up to limit unpublished events that are due for delivery to the caller for the
+// lease duration, by moving their next attempt past it, and returns them.
+// Only the oldest unpublished event of each aggregate is claimed, so the events of one aggregate are
+// delivered one after the other in the order they were stored, even when an earlier one is being
+// retried or is leased by another relay. Rows are locked with FOR UPDATE SKIP LOCKED while claiming,
+// so concurrent relays never claim the same event. The claim commits right away; an event whose
+// delivery isn't recorded becomes due again once its lease expires.
+func (r *eventRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]Event, common.AppError) {
+	query := `
+        UPDATE events
+        SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+        WHERE id IN (
+            SELECT id FROM events
+            WHERE published_at IS NULL AND next_attempt_at <= NOW()
+              AND NOT EXISTS (
+                  SELECT 1 FROM events earlier
+                  WHERE earlier.aggregate_id = events.aggregate_id AND earlier.published_at IS NULL
+                    AND earlier.sequence_number < events.sequence_number
+              )
+            ORDER BY next_attempt_at, sequence_number
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, aggregate_id, event_type, data, attempts, created_at, updated_at
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		r.l.Error("failed to claim pending events", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	events := make([]Event, 0, limit)
+	for rows.Next() {
+		var event Event
+		if err := rows.Scan(&event.ID, &event.AggregateID, &event.EventType, &event.Data, &event.Attempts,
+			&event.CreatedAt, &event.UpdatedAt); err != nil {
+			r.l.Error("failed to scan pending event", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.l.Error("failed to iterate pending events", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return events, nil
+}
+
+// MarkPublished records the successful delivery of a claimed event.
+func (r *eventRepository) MarkPublished(ctx context.Context, id uuid.UUID) common.AppError {
+	query := `
+        UPDATE events
+        SET published_at = NOW(), attempts = attempts + 1, last_error = NULL
+        WHERE id = $1
+    `
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		r.l.Error("failed to record event delivery", "eventId", id, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed delivery of a claimed event and schedules the next attempt after retryIn.
+func (r *eventRepository) MarkFailed(ctx context.Context, id uuid.UUID, deliveryErr error, retryIn time.Duration) common.AppError {
+	query := `
+        UPDATE events
+        SET attempts = attempts + 1, last_error = $2, next_attempt_at = NOW() + $3 * INTERVAL '1 millisecond'
+        WHERE id = $1
+    `
+
+	if _, err := r.db.ExecContext(ctx, query, id, deliveryErr.Error(), retryIn.Milliseconds()); err != nil {
+		r.l.Error("failed to record event delivery failure", "eventId", id, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
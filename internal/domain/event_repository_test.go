@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestEventRepositoryClaimPendingKeepsAggregateOrder(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewEventRepository(db, newTestLogger())
+	ctx := context.Background()
+
+	aggregateID := uuid.New()
+	t.Cleanup(func() {
+		if _, err := db.Exec("DELETE FROM events WHERE aggregate_id = $1", aggregateID); err != nil {
+			t.Errorf("failed to clean up test events: %v", err)
+		}
+	})
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	var stored []*CloudEvent
+	for _, eventType := range []string{EventCompanyCreated, EventCompanyUpdated} {
+		event, err := NewCloudEvent(ctx, eventType, aggregateID, struct{}{})
+		if err != nil {
+			t.Fatalf("NewCloudEvent() unexpected error = %v", err)
+		}
+
+		if appErr := repo.StoreEvent(ctx, tx, event); appErr != nil {
+			t.Fatalf("StoreEvent() unexpected error = %v", appErr)
+		}
+		stored = append(stored, event)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("failed to commit events: %v", err)
+	}
+
+	claimed := func() []uuid.UUID {
+		events, appErr := repo.ClaimPending(ctx, 1000, time.Minute)
+		if appErr != nil {
+			t.Fatalf("ClaimPending() unexpected error = %v", appErr)
+		}
+
+		var ids []uuid.UUID
+		for _, event := range events {
+			if event.AggregateID == aggregateID {
+				ids = append(ids, event.ID)
+			}
+		}
+		return ids
+	}
+
+	if ids := claimed(); len(ids) != 1 || ids[0] != stored[0].ID {
+		t.Fatalf("ClaimPending() got = %v, want only the first event %v", ids, stored[0].ID)
+	}
+
+	if ids := claimed(); len(ids) != 0 {
+		t.Errorf("ClaimPending() while the first event is leased got = %v, want none", ids)
+	}
+
+	if appErr := repo.MarkFailed(ctx, stored[0].ID, errors.New("broker unavailable"), 0); appErr != nil {
+		t.Fatalf("MarkFailed() unexpected error = %v", appErr)
+	}
+
+	if ids := claimed(); len(ids) != 1 || ids[0] != stored[0].ID {
+		t.Fatalf("ClaimPending() after a failure got = %v, want the first event %v again", ids, stored[0].ID)
+	}
+
+	if appErr := repo.MarkPublished(ctx, stored[0].ID); appErr != nil {
+		t.Fatalf("MarkPublished() unexpected error = %v", appErr)
+	}
+
+	if ids := claimed(); len(ids) != 1 || ids[0] != stored[1].ID {
+		t.Errorf("ClaimPending() after publishing got = %v, want the second event %v", ids, stored[1].ID)
+	}
+}
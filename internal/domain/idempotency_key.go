@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// IdempotencyKey records a request made with an Idempotency-Key header and the response it produced.
+// Keys are scoped per user. StatusCode is zero while the original request is still being processed;
+// once set, retries with the same key and RequestHash get the stored response replayed.
+type IdempotencyKey struct {
+	UserID          int
+	Key             string
+	RequestHash     string
+	StatusCode      int
+	ResponseHeaders map[string]string
+	ResponseBody    []byte
+	ExpiresAt       time.Time
+	CreatedAt       time.Time
+}
+
+// Completed reports whether the original request has finished and its response is stored.
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/ashtishad/xm/common"
+)
+
+// IdempotencyKeyRepository stores idempotency keys and the responses they replay.
+type IdempotencyKeyRepository interface {
+	Acquire(ctx context.Context, key *IdempotencyKey) (*IdempotencyKey, bool, common.AppError)
+	Complete(ctx context.Context, key *IdempotencyKey) common.AppError
+	Release(ctx context.Context, userID int, key string) common.AppError
+	DeleteExpired(ctx context.Context) (int64, common.AppError)
+}
+
+type idempotencyKeyRepository struct {
+	db *sql.DB
+	l  *slog.Logger
+}
+
+func NewIdempotencyKeyRepository(db *sql.DB, logger *slog.Logger) IdempotencyKeyRepository {
+	return &idempotencyKeyRepository{
+		db: db,
+		l:  logger,
+	}
+}
+
+// Acquire claims key for a new request. It reports true when the key was free, or had expired, and is
+// now held by the caller in the processing state. Otherwise it returns the stored key, which is either
+// still being processed or holds the response to replay.
+func (r *idempotencyKeyRepository) Acquire(ctx context.Context, key *IdempotencyKey) (*IdempotencyKey, bool, common.AppError) {
+	query := `
+        INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, expires_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (user_id, idempotency_key) DO UPDATE
+        SET request_hash = EXCLUDED.request_hash,
+            status_code = NULL,
+            response_headers = NULL,
+            response_body = NULL,
+            expires_at = EXCLUDED.expires_at,
+            created_at = NOW()
+        WHERE idempotency_keys.expires_at <= NOW()
+        RETURNING created_at
+    `
+
+	err := r.db.QueryRowContext(ctx, query, key.UserID, key.Key, key.RequestHash, key.ExpiresAt).Scan(&key.CreatedAt)
+	if err == nil {
+		return key, true, nil
+	}
+
+	if err != sql.ErrNoRows {
+		r.l.Error("failed to acquire idempotency key", "err", err)
+		return nil, false, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	existing, appErr := r.find(ctx, key.UserID, key.Key)
+	if appErr != nil {
+		return nil, false, appErr
+	}
+
+	return existing, false, nil
+}
+
+// Complete stores the response of the request holding the key.
+func (r *idempotencyKeyRepository) Complete(ctx context.Context, key *IdempotencyKey) common.AppError {
+	headers, err := json.Marshal(key.ResponseHeaders)
+	if err != nil {
+		r.l.Error("failed to marshal idempotent response headers", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedServer, err)
+	}
+
+	query := `
+        UPDATE idempotency_keys
+        SET status_code = $3, response_headers = $4, response_body = $5
+        WHERE user_id = $1 AND idempotency_key = $2
+    `
+
+	if _, err := r.db.ExecContext(ctx, query, key.UserID, key.Key, key.StatusCode, headers, key.ResponseBody); err != nil {
+		r.l.Error("failed to store idempotent response", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// Release frees a key whose request failed without a response worth replaying, so it can be retried.
+func (r *idempotencyKeyRepository) Release(ctx context.Context, userID int, key string) common.AppError {
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, key); err != nil {
+		r.l.Error("failed to release idempotency key", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes keys past their expiry and returns how many were removed.
+func (r *idempotencyKeyRepository) DeleteExpired(ctx context.Context) (int64, common.AppError) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		r.l.Error("failed to delete expired idempotency keys", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		r.l.Error("failed to count deleted idempotency keys", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return deleted, nil
+}
+
+func (r *idempotencyKeyRepository) find(ctx context.Context, userID int, key string) (*IdempotencyKey, common.AppError) {
+	query := `
+        SELECT user_id, idempotency_key, request_hash, status_code, response_headers, response_body, expires_at, created_at
+        FROM idempotency_keys
+        WHERE user_id = $1 AND idempotency_key = $2
+    `
+
+	var stored IdempotencyKey
+	var statusCode sql.NullInt64
+	var headers []byte
+
+	err := r.db.QueryRowContext(ctx, query, userID, key).Scan(&stored.UserID, &stored.Key, &stored.RequestHash,
+		&statusCode, &headers, &stored.ResponseBody, &stored.ExpiresAt, &stored.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// Released between the insert attempt and this read; the caller may simply retry.
+			return nil, common.NewConflictError("request with this Idempotency-Key is being processed, retry later")
+		}
+		r.l.Error("failed to get idempotency key", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	stored.StatusCode = int(statusCode.Int64)
+
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &stored.ResponseHeaders); err != nil {
+			r.l.Error("failed to unmarshal idempotent response headers", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+	}
+
+	return &stored, nil
+}
@@ -0,0 +1,57 @@
+package domain
+
+// UserRole determines what a user is allowed to do.
+// @Description UserRole can be admin, editor, or viewer.
+type UserRole string
+
+const (
+	UserRoleAdmin  UserRole = "admin"
+	UserRoleEditor UserRole = "editor"
+	UserRoleViewer UserRole = "viewer"
+)
+
+// Permission is a single action that can be granted to a role.
+type Permission string
+
+const (
+	PermissionCompaniesRead   Permission = "companies:read"
+	PermissionCompaniesWrite  Permission = "companies:write"
+	PermissionCompaniesDelete Permission = "companies:delete"
+	PermissionUsersManage     Permission = "users:manage"
+)
+
+// rolePermissions maps each role to the permissions it grants.
+var rolePermissions = map[UserRole][]Permission{
+	UserRoleAdmin: {
+		PermissionCompaniesRead, PermissionCompaniesWrite, PermissionCompaniesDelete,
+		PermissionUsersManage,
+	},
+	UserRoleEditor: {
+		PermissionCompaniesRead, PermissionCompaniesWrite, PermissionCompaniesDelete,
+	},
+	UserRoleViewer: {
+		PermissionCompaniesRead,
+	},
+}
+
+// Can reports whether the role grants the permission. Unknown roles grant nothing.
+func (r UserRole) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanManageCompany reports whether the user may modify or delete the company.
+// Admins manage every company; other users only the companies they own.
+// Companies without an owner, created before ownership was tracked, are admin only.
+func CanManageCompany(user *User, company *Company) bool {
+	if user.Role == UserRoleAdmin {
+		return true
+	}
+
+	return company.OwnerID != nil && *company.OwnerID == user.UUID
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUserRole_Can(t *testing.T) {
+	tests := []struct {
+		role       UserRole
+		permission Permission
+		want       bool
+	}{
+		{UserRoleAdmin, PermissionUsersManage, true},
+		{UserRoleAdmin, PermissionCompaniesDelete, true},
+		{UserRoleEditor, PermissionCompaniesWrite, true},
+		{UserRoleEditor, PermissionCompaniesDelete, true},
+		{UserRoleEditor, PermissionUsersManage, false},
+		{UserRoleViewer, PermissionCompaniesRead, true},
+		{UserRoleViewer, PermissionCompaniesWrite, false},
+		{UserRoleViewer, PermissionCompaniesDelete, false},
+		{UserRole("unknown"), PermissionCompaniesRead, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.Can(tt.permission); got != tt.want {
+			t.Errorf("%s.Can(%s) got = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestCanManageCompany(t *testing.T) {
+	owner := uuid.New()
+
+	tests := []struct {
+		name    string
+		user    User
+		ownerID *uuid.UUID
+		want    bool
+	}{
+		{"Owner", User{UUID: owner, Role: UserRoleEditor}, &owner, true},
+		{"Other editor", User{UUID: uuid.New(), Role: UserRoleEditor}, &owner, false},
+		{"Admin", User{UUID: uuid.New(), Role: UserRoleAdmin}, &owner, true},
+		{"Unowned as editor", User{UUID: owner, Role: UserRoleEditor}, nil, false},
+		{"Unowned as admin", User{UUID: uuid.New(), Role: UserRoleAdmin}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			company := &Company{ID: uuid.New(), OwnerID: tt.ownerID}
+			if got := CanManageCompany(&tt.user, company); got != tt.want {
+				t.Errorf("CanManageCompany() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
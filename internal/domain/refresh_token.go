@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a stored, hashed refresh token.
+// Tokens issued by rotating one another share a FamilyID; RotatedAt is set once a token
+// has been exchanged and RevokedAt once its family has been invalidated.
+type RefreshToken struct {
+	ID        uuid.UUID
+	UserID    int
+	FamilyID  uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xm/common"
+)
+
+// RefreshTokenRepository stores refresh tokens and enforces single-use rotation.
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *RefreshToken) common.AppError
+	Rotate(ctx context.Context, tokenHash string, next *RefreshToken) (*RefreshToken, common.AppError)
+	RevokeFamily(ctx context.Context, userID int, tokenHash string) common.AppError
+	DeleteExpired(ctx context.Context) (int64, common.AppError)
+}
+
+type refreshTokenRepository struct {
+	db *sql.DB
+	l  *slog.Logger
+}
+
+func NewRefreshTokenRepository(db *sql.DB, logger *slog.Logger) RefreshTokenRepository {
+	return &refreshTokenRepository{
+		db: db,
+		l:  logger,
+	}
+}
+
+// Create stores the first refresh token of a family, issued on login or registration.
+func (r *refreshTokenRepository) Create(ctx context.Context, token *RefreshToken) common.AppError {
+	query := `
+        INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at
+    `
+
+	err := r.db.QueryRowContext(ctx, query, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		r.l.Error("failed to create refresh token", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// Rotate exchanges the refresh token identified by tokenHash for next, which joins the same family.
+// Returns the exchanged token, whose UserID identifies the owner.
+// Presenting a token that was already rotated means it has leaked, so the whole family is revoked
+// and every token descending from the original login stops working.
+// Locks the presented token row so concurrent refreshes with the same token cannot both succeed.
+func (r *refreshTokenRepository) Rotate(ctx context.Context, tokenHash string, next *RefreshToken) (*RefreshToken, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		r.l.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer r.rollBackOnError(tx)
+
+	query := `
+        SELECT id, user_id, family_id, token_hash, expires_at, rotated_at, revoked_at, created_at
+        FROM refresh_tokens
+        WHERE token_hash = $1
+        FOR UPDATE
+    `
+
+	var current RefreshToken
+	err = tx.QueryRowContext(ctx, query, tokenHash).Scan(
+		&current.ID, &current.UserID, &current.FamilyID, &current.TokenHash,
+		&current.ExpiresAt, &current.RotatedAt, &current.RevokedAt, &current.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.NewUnauthorizedError(common.ErrInvalidRefreshToken)
+		}
+		r.l.Error("failed to get refresh token", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if current.RevokedAt != nil {
+		return nil, common.NewUnauthorizedError(common.ErrInvalidRefreshToken)
+	}
+
+	if current.RotatedAt != nil {
+		r.l.Warn("refresh token reuse detected, revoking token family", "userId", current.UserID, "familyId", current.FamilyID)
+
+		if appErr := r.revokeFamily(ctx, tx, current); appErr != nil {
+			return nil, appErr
+		}
+
+		return nil, common.NewUnauthorizedError(common.ErrInvalidRefreshToken)
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, common.NewUnauthorizedError(common.ErrInvalidRefreshToken)
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET rotated_at = NOW() WHERE id = $1`, current.ID); err != nil {
+		r.l.Error("failed to rotate refresh token", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	next.UserID = current.UserID
+	next.FamilyID = current.FamilyID
+
+	insertQuery := `
+        INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at
+    `
+
+	err = tx.QueryRowContext(ctx, insertQuery, next.UserID, next.FamilyID, next.TokenHash, next.ExpiresAt).
+		Scan(&next.ID, &next.CreatedAt)
+	if err != nil {
+		r.l.Error("failed to create rotated refresh token", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.l.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return &current, nil
+}
+
+// RevokeFamily revokes the refresh token identified by tokenHash together with every token
+// rotated from the same login, provided the token belongs to userID.
+// Unknown tokens and tokens of other users are ignored.
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, userID int, tokenHash string) common.AppError {
+	query := `
+        UPDATE refresh_tokens
+        SET revoked_at = NOW()
+        WHERE family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $1 AND user_id = $2)
+          AND user_id = $2 AND revoked_at IS NULL
+    `
+
+	if _, err := r.db.ExecContext(ctx, query, tokenHash, userID); err != nil {
+		r.l.Error("failed to revoke refresh token family", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes refresh tokens past their expiry and returns how many were removed.
+// Rotated and revoked tokens are kept until then, so presenting them again is still detected as reuse.
+func (r *refreshTokenRepository) DeleteExpired(ctx context.Context) (int64, common.AppError) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at <= NOW()`)
+	if err != nil {
+		r.l.Error("failed to delete expired refresh tokens", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		r.l.Error("failed to count deleted refresh tokens", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return deleted, nil
+}
+
+// revokeFamily revokes every live token of the presented token's family and commits,
+// so the revocation persists even though the refresh itself is rejected.
+func (r *refreshTokenRepository) revokeFamily(ctx context.Context, tx *sql.Tx, token RefreshToken) common.AppError {
+	query := `
+        UPDATE refresh_tokens
+        SET revoked_at = NOW()
+        WHERE family_id = $1 AND revoked_at IS NULL
+    `
+
+	if _, err := tx.ExecContext(ctx, query, token.FamilyID); err != nil {
+		r.l.Error("failed to revoke refresh token family", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.l.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+func (r *refreshTokenRepository) rollBackOnError(tx *sql.Tx) {
+	if rbErr := tx.Rollback(); rbErr != nil && rbErr != sql.ErrTxDone {
+		r.l.Error(common.ErrTXRollback, "rbErr", rbErr)
+	}
+}
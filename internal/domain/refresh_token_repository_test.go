@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRefreshTokenRepositoryRevokeFamily(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewRefreshTokenRepository(db, newTestLogger())
+	ctx := context.Background()
+
+	owner := newTestUser(t, db)
+	other := newTestUser(t, db)
+
+	token := &RefreshToken{
+		UserID:    owner,
+		FamilyID:  uuid.New(),
+		TokenHash: uuid.NewString() + uuid.NewString()[:28],
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if appErr := repo.Create(ctx, token); appErr != nil {
+		t.Fatalf("Create() unexpected error = %v", appErr)
+	}
+
+	revoked := func() bool {
+		var revokedAt *time.Time
+		if err := db.QueryRow("SELECT revoked_at FROM refresh_tokens WHERE id = $1", token.ID).Scan(&revokedAt); err != nil {
+			t.Fatalf("failed to read refresh token: %v", err)
+		}
+		return revokedAt != nil
+	}
+
+	if appErr := repo.RevokeFamily(ctx, other, token.TokenHash); appErr != nil {
+		t.Fatalf("RevokeFamily() unexpected error = %v", appErr)
+	}
+
+	if revoked() {
+		t.Errorf("RevokeFamily() by another user revoked the token")
+	}
+
+	if appErr := repo.RevokeFamily(ctx, owner, token.TokenHash); appErr != nil {
+		t.Fatalf("RevokeFamily() unexpected error = %v", appErr)
+	}
+
+	if !revoked() {
+		t.Errorf("RevokeFamily() by the owner left the token live")
+	}
+}
+
+func TestRefreshTokenRepositoryDeleteExpired(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewRefreshTokenRepository(db, newTestLogger())
+	ctx := context.Background()
+
+	userID := newTestUser(t, db)
+	expired := &RefreshToken{UserID: userID, FamilyID: uuid.New(), TokenHash: uuid.NewString() + uuid.NewString()[:28], ExpiresAt: time.Now().Add(-time.Minute)}
+	live := &RefreshToken{UserID: userID, FamilyID: uuid.New(), TokenHash: uuid.NewString() + uuid.NewString()[:28], ExpiresAt: time.Now().Add(time.Hour)}
+
+	for _, token := range []*RefreshToken{expired, live} {
+		if appErr := repo.Create(ctx, token); appErr != nil {
+			t.Fatalf("Create() unexpected error = %v", appErr)
+		}
+	}
+
+	if _, appErr := repo.DeleteExpired(ctx); appErr != nil {
+		t.Fatalf("DeleteExpired() unexpected error = %v", appErr)
+	}
+
+	var remaining []uuid.UUID
+	rows, err := db.Query("SELECT id FROM refresh_tokens WHERE user_id = $1", userID)
+	if err != nil {
+		t.Fatalf("failed to read refresh tokens: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("failed to scan refresh token: %v", err)
+		}
+		remaining = append(remaining, id)
+	}
+
+	if len(remaining) != 1 || remaining[0] != live.ID {
+		t.Errorf("DeleteExpired() left tokens = %v, want only %v", remaining, live.ID)
+	}
+}
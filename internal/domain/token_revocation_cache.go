@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ashtishad/xm/common"
+	"github.com/google/uuid"
+)
+
+// revocationCacheEntry is a cached revocation check result for one token.
+type revocationCacheEntry struct {
+	userID    int
+	revoked   bool
+	expiresAt time.Time
+}
+
+// cachedTokenRevocationRepository keeps revocation checks in process memory, so authenticated
+// requests do not hit the database for every call.
+// Check results are cached for ttl, which bounds how long a revocation made by another server
+// instance can go unnoticed. Revocations made through this instance update the cache immediately.
+type cachedTokenRevocationRepository struct {
+	repo TokenRevocationRepository
+	ttl  time.Duration
+
+	mu        sync.Mutex
+	entries   map[uuid.UUID]revocationCacheEntry
+	lastPrune time.Time
+}
+
+// NewCachedTokenRevocationRepository wraps repo with an in-process cache whose entries live for ttl.
+func NewCachedTokenRevocationRepository(repo TokenRevocationRepository, ttl time.Duration) TokenRevocationRepository {
+	return &cachedTokenRevocationRepository{
+		repo:      repo,
+		ttl:       ttl,
+		entries:   make(map[uuid.UUID]revocationCacheEntry),
+		lastPrune: time.Now(),
+	}
+}
+
+func (c *cachedTokenRevocationRepository) RevokeToken(ctx context.Context, jti uuid.UUID, userID int, expiresAt time.Time) common.AppError {
+	if appErr := c.repo.RevokeToken(ctx, jti, userID, expiresAt); appErr != nil {
+		return appErr
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[jti] = revocationCacheEntry{userID: userID, revoked: true, expiresAt: expiresAt}
+	return nil
+}
+
+// RevokeAllForUser drops the user's cached entries so the next check reads the new cutoff.
+func (c *cachedTokenRevocationRepository) RevokeAllForUser(ctx context.Context, userID int) common.AppError {
+	if appErr := c.repo.RevokeAllForUser(ctx, userID); appErr != nil {
+		return appErr
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for jti, entry := range c.entries {
+		if entry.userID == userID {
+			delete(c.entries, jti)
+		}
+	}
+
+	return nil
+}
+
+func (c *cachedTokenRevocationRepository) IsRevoked(ctx context.Context, jti uuid.UUID, userID int, issuedAt time.Time) (bool, common.AppError) {
+	now := time.Now()
+
+	c.mu.Lock()
+	c.pruneLocked(now)
+	entry, ok := c.entries[jti]
+	c.mu.Unlock()
+
+	if ok && now.Before(entry.expiresAt) {
+		return entry.revoked, nil
+	}
+
+	revoked, appErr := c.repo.IsRevoked(ctx, jti, userID, issuedAt)
+	if appErr != nil {
+		return false, appErr
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[jti] = revocationCacheEntry{userID: userID, revoked: revoked, expiresAt: now.Add(c.ttl)}
+	return revoked, nil
+}
+
+// DeleteExpired deletes expired revocations from the wrapped repository. Cached entries expire on their own.
+func (c *cachedTokenRevocationRepository) DeleteExpired(ctx context.Context) (int64, common.AppError) {
+	return c.repo.DeleteExpired(ctx)
+}
+
+// pruneLocked evicts stale entries at most once per ttl. Callers must hold c.mu.
+func (c *cachedTokenRevocationRepository) pruneLocked(now time.Time) {
+	if now.Sub(c.lastPrune) < c.ttl {
+		return
+	}
+
+	for jti, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, jti)
+		}
+	}
+
+	c.lastPrune = now
+}
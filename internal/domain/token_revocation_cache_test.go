@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xm/common"
+	"github.com/google/uuid"
+)
+
+// countingRevocationRepository is an in-memory TokenRevocationRepository that counts IsRevoked lookups.
+type countingRevocationRepository struct {
+	revoked map[uuid.UUID]bool
+	cutoffs map[int]time.Time
+	lookups int
+}
+
+func newCountingRevocationRepository() *countingRevocationRepository {
+	return &countingRevocationRepository{revoked: make(map[uuid.UUID]bool), cutoffs: make(map[int]time.Time)}
+}
+
+func (r *countingRevocationRepository) RevokeToken(_ context.Context, jti uuid.UUID, _ int, _ time.Time) common.AppError {
+	r.revoked[jti] = true
+	return nil
+}
+
+func (r *countingRevocationRepository) RevokeAllForUser(_ context.Context, userID int) common.AppError {
+	r.cutoffs[userID] = time.Now()
+	return nil
+}
+
+func (r *countingRevocationRepository) IsRevoked(_ context.Context, jti uuid.UUID, userID int, issuedAt time.Time) (bool, common.AppError) {
+	r.lookups++
+	cutoff, ok := r.cutoffs[userID]
+	return r.revoked[jti] || (ok && !cutoff.Before(issuedAt)), nil
+}
+
+func (r *countingRevocationRepository) DeleteExpired(_ context.Context) (int64, common.AppError) {
+	return 0, nil
+}
+
+func TestCachedTokenRevocationRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := newCountingRevocationRepository()
+	cache := NewCachedTokenRevocationRepository(repo, time.Minute)
+
+	jti, userID, issuedAt := uuid.New(), 1, time.Now().Add(-time.Second)
+
+	for i := 0; i < 3; i++ {
+		if revoked, _ := cache.IsRevoked(ctx, jti, userID, issuedAt); revoked {
+			t.Fatalf("IsRevoked() got = true for a valid token")
+		}
+	}
+	if repo.lookups != 1 {
+		t.Errorf("IsRevoked() hit the repository %d times, want 1", repo.lookups)
+	}
+
+	if appErr := cache.RevokeToken(ctx, jti, userID, time.Now().Add(time.Hour)); appErr != nil {
+		t.Fatalf("RevokeToken() unexpected error = %v", appErr)
+	}
+	if revoked, _ := cache.IsRevoked(ctx, jti, userID, issuedAt); !revoked {
+		t.Errorf("IsRevoked() got = false right after RevokeToken")
+	}
+
+	other := uuid.New()
+	cache.IsRevoked(ctx, other, userID, issuedAt)
+	if appErr := cache.RevokeAllForUser(ctx, userID); appErr != nil {
+		t.Fatalf("RevokeAllForUser() unexpected error = %v", appErr)
+	}
+	if revoked, _ := cache.IsRevoked(ctx, other, userID, issuedAt); !revoked {
+		t.Errorf("IsRevoked() got = false for a cached token after RevokeAllForUser")
+	}
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xm/common"
+	"github.com/google/uuid"
+)
+
+// TokenRevocationRepository records revoked access tokens.
+// A token is revoked either individually by its jti, or by a per-user cutoff that revokes
+// every token the user was issued up to that moment.
+type TokenRevocationRepository interface {
+	RevokeToken(ctx context.Context, jti uuid.UUID, userID int, expiresAt time.Time) common.AppError
+	RevokeAllForUser(ctx context.Context, userID int) common.AppError
+	IsRevoked(ctx context.Context, jti uuid.UUID, userID int, issuedAt time.Time) (bool, common.AppError)
+	DeleteExpired(ctx context.Context) (int64, common.AppError)
+}
+
+type tokenRevocationRepository struct {
+	db *sql.DB
+	l  *slog.Logger
+}
+
+func NewTokenRevocationRepository(db *sql.DB, logger *slog.Logger) TokenRevocationRepository {
+	return &tokenRevocationRepository{
+		db: db,
+		l:  logger,
+	}
+}
+
+// RevokeToken revokes a single access token. Revoking the same token twice is a no-op.
+func (r *tokenRevocationRepository) RevokeToken(ctx context.Context, jti uuid.UUID, userID int, expiresAt time.Time) common.AppError {
+	query := `
+        INSERT INTO revoked_tokens (jti, user_id, expires_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (jti) DO NOTHING
+    `
+
+	if _, err := r.db.ExecContext(ctx, query, jti, userID, expiresAt); err != nil {
+		r.l.Error("failed to revoke token", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// RevokeAllForUser revokes every access token issued to the user so far, and all of the user's
+// refresh tokens, so no existing session can continue or be renewed.
+func (r *tokenRevocationRepository) RevokeAllForUser(ctx context.Context, userID int) common.AppError {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		r.l.Error(common.ErrTXBegin, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer r.rollBackOnError(tx)
+
+	cutoffQuery := `
+        INSERT INTO user_token_revocations (user_id, revoked_before)
+        VALUES ($1, NOW())
+        ON CONFLICT (user_id) DO UPDATE SET revoked_before = EXCLUDED.revoked_before
+    `
+
+	if _, err = tx.ExecContext(ctx, cutoffQuery, userID); err != nil {
+		r.l.Error("failed to revoke user tokens", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	refreshQuery := `
+        UPDATE refresh_tokens
+        SET revoked_at = NOW()
+        WHERE user_id = $1 AND revoked_at IS NULL
+    `
+
+	if _, err = tx.ExecContext(ctx, refreshQuery, userID); err != nil {
+		r.l.Error("failed to revoke user refresh tokens", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.l.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// IsRevoked reports whether the token with the given jti, issued to userID at issuedAt, has been revoked.
+// JWT issued-at times have second precision, so a token issued in the same second as a
+// user-wide revocation is treated as revoked.
+func (r *tokenRevocationRepository) IsRevoked(ctx context.Context, jti uuid.UUID, userID int, issuedAt time.Time) (bool, common.AppError) {
+	query := `
+        SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)
+            OR EXISTS (SELECT 1 FROM user_token_revocations WHERE user_id = $2 AND revoked_before >= $3)
+    `
+
+	var revoked bool
+	if err := r.db.QueryRowContext(ctx, query, jti, userID, issuedAt).Scan(&revoked); err != nil {
+		r.l.Error("failed to check token revocation", "err", err)
+		return false, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return revoked, nil
+}
+
+// DeleteExpired removes the revocations of individual tokens that have expired by now, which are
+// rejected as expired regardless, and returns how many were removed.
+func (r *tokenRevocationRepository) DeleteExpired(ctx context.Context) (int64, common.AppError) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= NOW()`)
+	if err != nil {
+		r.l.Error("failed to delete expired token revocations", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		r.l.Error("failed to count deleted token revocations", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return deleted, nil
+}
+
+func (r *tokenRevocationRepository) rollBackOnError(tx *sql.Tx) {
+	if rbErr := tx.Rollback(); rbErr != nil && rbErr != sql.ErrTxDone {
+		r.l.Error(common.ErrTXRollback, "rbErr", rbErr)
+	}
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTokenRevocationRepositoryDeleteExpired(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewTokenRevocationRepository(db, newTestLogger())
+	ctx := context.Background()
+
+	userID := newTestUser(t, db)
+	expired, live := uuid.New(), uuid.New()
+
+	if appErr := repo.RevokeToken(ctx, expired, userID, time.Now().Add(-time.Minute)); appErr != nil {
+		t.Fatalf("RevokeToken() unexpected error = %v", appErr)
+	}
+	if appErr := repo.RevokeToken(ctx, live, userID, time.Now().Add(time.Hour)); appErr != nil {
+		t.Fatalf("RevokeToken() unexpected error = %v", appErr)
+	}
+
+	if _, appErr := repo.DeleteExpired(ctx); appErr != nil {
+		t.Fatalf("DeleteExpired() unexpected error = %v", appErr)
+	}
+
+	for jti, want := range map[uuid.UUID]bool{expired: false, live: true} {
+		var exists bool
+		if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)", jti).Scan(&exists); err != nil {
+			t.Fatalf("failed to read revoked token: %v", err)
+		}
+
+		if exists != want {
+			t.Errorf("DeleteExpired() kept revocation %v = %v, want %v", jti, exists, want)
+		}
+	}
+}
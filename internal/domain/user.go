@@ -26,6 +26,7 @@ type User struct {
 	Name         string     `json:"name"`
 	PasswordHash string     `json:"-"`
 	Status       UserStatus `json:"status"`
+	Role         UserRole   `json:"role"`
 	CreatedAt    *time.Time `json:"createdAt"`
 	UpdatedAt    *time.Time `json:"updatedAt"`
 }
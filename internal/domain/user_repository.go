@@ -7,11 +7,13 @@ import (
 	"log/slog"
 
 	"github.com/ashtishad/xm/common"
+	"github.com/google/uuid"
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *User) (*User, common.AppError)
 	FindBy(ctx context.Context, dbColumnName string, value any) (*User, common.AppError)
+	UpdateRole(ctx context.Context, id uuid.UUID, role UserRole) (*User, common.AppError)
 }
 
 type userRepository struct {
@@ -46,13 +48,13 @@ func (r userRepository) Create(ctx context.Context, user *User) (*User, common.A
 		return nil, common.NewConflictError("user with this email already exists")
 	}
 
-	queryCreateUser := `INSERT INTO users (uuid, email, name, password_hash, status, created_at, updated_at)
-                        VALUES($1, $2, $3, $4, $5, $6, $7)
+	queryCreateUser := `INSERT INTO users (uuid, email, name, password_hash, status, role, created_at, updated_at)
+                        VALUES($1, $2, $3, $4, $5, $6, $7, $8)
                         RETURNING id`
 
 	var createdID int
 	err = tx.QueryRowContext(ctx, queryCreateUser,
-		user.UUID, user.Email, user.Name, user.PasswordHash, user.Status, user.CreatedAt, user.UpdatedAt).Scan(&createdID)
+		user.UUID, user.Email, user.Name, user.PasswordHash, user.Status, user.Role, user.CreatedAt, user.UpdatedAt).Scan(&createdID)
 
 	if err != nil {
 		r.l.Error("failed to create user", "err", err)
@@ -85,7 +87,7 @@ func (r userRepository) FindBy(ctx context.Context, dbColumnName string, value a
 	var user User
 	err = tx.QueryRowContext(ctx, query, value).Scan(
 		&user.ID, &user.UUID, &user.Email, &user.Name, &user.PasswordHash,
-		&user.Status, &user.CreatedAt, &user.UpdatedAt)
+		&user.Status, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -104,6 +106,28 @@ func (r userRepository) FindBy(ctx context.Context, dbColumnName string, value a
 	return &user, nil
 }
 
+// UpdateRole changes the role of the user identified by its UUID and returns the updated user.
+func (r userRepository) UpdateRole(ctx context.Context, id uuid.UUID, role UserRole) (*User, common.AppError) {
+	query := `UPDATE users SET role = $1 WHERE uuid = $2
+              RETURNING id, uuid, email, name, password_hash, status, role, created_at, updated_at`
+
+	var user User
+	err := r.db.QueryRowContext(ctx, query, role, id).Scan(
+		&user.ID, &user.UUID, &user.Email, &user.Name, &user.PasswordHash,
+		&user.Status, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.NewNotFoundError("user not found")
+		}
+
+		r.l.Error("failed to update user role", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return &user, nil
+}
+
 func (r userRepository) rollBackOnError(tx *sql.Tx) {
 	if rbErr := tx.Rollback(); rbErr != nil && rbErr != sql.ErrTxDone {
 		r.l.Error(common.ErrTXRollback, "rbErr", rbErr)
@@ -117,13 +141,13 @@ func generateFindByQuery(fieldName string) (string, error) {
 
 	switch fieldName {
 	case common.DBColumnID:
-		query = `SELECT id, uuid, email, name, password_hash, status, created_at, updated_at
+		query = `SELECT id, uuid, email, name, password_hash, status, role, created_at, updated_at
                  FROM users WHERE id = $1`
 	case common.DBColumnUUID:
-		query = `SELECT id, uuid, email, name, password_hash, status, created_at, updated_at
+		query = `SELECT id, uuid, email, name, password_hash, status, role, created_at, updated_at
                  FROM users WHERE uuid = $1`
 	case common.DBColumnEmail:
-		query = `SELECT id, uuid, email, name, password_hash, status, created_at, updated_at
+		query = `SELECT id, uuid, email, name, password_hash, status, role, created_at, updated_at
                  FROM users WHERE email = $1`
 	default:
 		return "", errors.New("invalid db field name")
@@ -0,0 +1,66 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ashtishad/xm/internal/domain"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// cloudEventContentType is the media type of a CloudEvent in structured JSON mode.
+const cloudEventContentType = "application/cloudevents+json; charset=UTF-8"
+
+// KafkaPublisher publishes outbox events to a Kafka topic.
+// The CloudEvent envelope is the record value (structured content mode); the event id and type
+// travel as record headers as well.
+// Records are keyed by the aggregate ID, so all events of one company land on the same
+// partition and keep their order.
+type KafkaPublisher struct {
+	writer *kafkago.Writer
+}
+
+// NewKafkaPublisher creates a KafkaPublisher writing to topic on brokers.
+// Every Publish is flushed on its own and waits for all in-sync replicas, so a nil error
+// means the event is durably stored.
+func NewKafkaPublisher(brokers []string, topic string) (*KafkaPublisher, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("at least one kafka broker is required")
+	}
+
+	return &KafkaPublisher{
+		writer: &kafkago.Writer{
+			Addr:                   kafkago.TCP(brokers...),
+			Topic:                  topic,
+			Balancer:               &kafkago.Hash{},
+			RequiredAcks:           kafkago.RequireAll,
+			BatchSize:              1,
+			MaxAttempts:            3,
+			WriteTimeout:           10 * time.Second,
+			AllowAutoTopicCreation: true,
+		},
+	}, nil
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, event domain.Event) error {
+	return p.writer.WriteMessages(ctx, kafkaMessage(event))
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
+
+// kafkaMessage maps an outbox event to its Kafka record.
+func kafkaMessage(event domain.Event) kafkago.Message {
+	return kafkago.Message{
+		Key:   []byte(event.AggregateID.String()),
+		Value: event.Data,
+		Headers: []kafkago.Header{
+			{Key: "event_id", Value: []byte(event.ID.String())},
+			{Key: "event_type", Value: []byte(event.EventType)},
+			{Key: "content-type", Value: []byte(cloudEventContentType)},
+		},
+		Time: event.CreatedAt,
+	}
+}
@@ -0,0 +1,55 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestNewKafkaPublisherRequiresBrokers(t *testing.T) {
+	if _, err := NewKafkaPublisher(nil, "company-events"); err == nil {
+		t.Errorf("NewKafkaPublisher() got nil error, want error for missing brokers")
+	}
+}
+
+func TestKafkaMessage(t *testing.T) {
+	event := domain.Event{
+		ID:          uuid.New(),
+		AggregateID: uuid.New(),
+		EventType:   domain.EventCompanyCreated,
+		Data:        []byte(`{"specversion":"1.0"}`),
+		CreatedAt:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	msg := kafkaMessage(event)
+
+	if string(msg.Key) != event.AggregateID.String() {
+		t.Errorf("kafkaMessage() got key = %s, want %s", msg.Key, event.AggregateID)
+	}
+
+	if string(msg.Value) != string(event.Data) {
+		t.Errorf("kafkaMessage() got value = %s, want %s", msg.Value, event.Data)
+	}
+
+	if !msg.Time.Equal(event.CreatedAt) {
+		t.Errorf("kafkaMessage() got time = %v, want %v", msg.Time, event.CreatedAt)
+	}
+
+	wantHeaders := map[string]string{
+		"event_id":     event.ID.String(),
+		"event_type":   event.EventType,
+		"content-type": cloudEventContentType,
+	}
+
+	if len(msg.Headers) != len(wantHeaders) {
+		t.Fatalf("kafkaMessage() got %d headers, want %d", len(msg.Headers), len(wantHeaders))
+	}
+
+	for _, h := range msg.Headers {
+		if want, ok := wantHeaders[h.Key]; !ok || string(h.Value) != want {
+			t.Errorf("kafkaMessage() got header %s = %s, want %s", h.Key, h.Value, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ashtishad/xm/internal/domain"
+)
+
+// EventPublisher delivers outbox events to a message broker.
+// Publish must return only after the broker has durably accepted the event; a returned
+// error leaves the event in the outbox to be retried.
+type EventPublisher interface {
+	Publish(ctx context.Context, event domain.Event) error
+	Close() error
+}
+
+// MemoryPublisher is an in-memory EventPublisher for tests and local development.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []domain.Event
+	err    error
+}
+
+// NewMemoryPublisher creates an empty MemoryPublisher.
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish records the event, or returns the error set with FailWith.
+func (p *MemoryPublisher) Publish(_ context.Context, event domain.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+
+	p.events = append(p.events, event)
+	return nil
+}
+
+// FailWith makes subsequent Publish calls return err; nil restores normal behavior.
+func (p *MemoryPublisher) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = err
+}
+
+// Events returns a copy of the events published so far.
+func (p *MemoryPublisher) Events() []domain.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]domain.Event(nil), p.events...)
+}
+
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
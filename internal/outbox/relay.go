@@ -0,0 +1,143 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xm/internal/domain"
+)
+
+// RelayConfig holds the polling and retry settings of a Relay.
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	Lease        time.Duration
+}
+
+// Relay moves events from the events outbox table to an EventPublisher.
+// Delivery is at-least-once: an event published just before a crash may be sent again.
+// The events of one company are published in order: a failed event is retried with exponential
+// backoff, holding back the later events of its company while those of other companies keep flowing.
+// Claimed events are published outside of any database transaction; each batch is leased for Lease,
+// after which unpublished events of the batch can be claimed again.
+type Relay struct {
+	eventRepo domain.EventRepository
+	publisher EventPublisher
+	cfg       RelayConfig
+	l         *slog.Logger
+}
+
+// NewRelay creates a Relay, filling unset config values with defaults.
+func NewRelay(eventRepo domain.EventRepository, publisher EventPublisher, cfg RelayConfig, l *slog.Logger) *Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = time.Second
+	}
+
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = 5 * time.Minute
+	}
+
+	if cfg.Lease <= 0 {
+		cfg.Lease = time.Minute
+	}
+
+	return &Relay{
+		eventRepo: eventRepo,
+		publisher: publisher,
+		cfg:       cfg,
+		l:         l,
+	}
+}
+
+// Run polls the outbox until ctx is canceled, then closes the publisher.
+// A batch that published anything is followed immediately by another poll to drain backlogs quickly,
+// since the next event of a company only becomes due once the previous one is published.
+func (r *Relay) Run(ctx context.Context) {
+	r.l.Info("outbox relay started", "pollInterval", r.cfg.PollInterval, "batchSize", r.cfg.BatchSize)
+
+	defer func() {
+		if err := r.publisher.Close(); err != nil {
+			r.l.Error("failed to close event publisher", "err", err)
+		}
+		r.l.Info("outbox relay stopped")
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		published, err := r.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.l.Error("outbox relay poll failed", "err", err)
+		}
+
+		if published > 0 {
+			timer.Reset(0)
+		} else {
+			timer.Reset(r.cfg.PollInterval)
+		}
+	}
+}
+
+// RunOnce publishes a single batch of due events and returns how many were published.
+// Events not reached before the lease of the batch expires are left for a later claim.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	events, appErr := r.eventRepo.ClaimPending(ctx, r.cfg.BatchSize, r.cfg.Lease)
+	if appErr != nil {
+		return 0, appErr
+	}
+
+	leaseCtx, cancel := context.WithTimeout(ctx, r.cfg.Lease)
+	defer cancel()
+
+	published := 0
+	for _, event := range events {
+		if leaseCtx.Err() != nil {
+			break
+		}
+
+		if err := r.publisher.Publish(leaseCtx, event); err != nil {
+			delay := r.backoff(event.Attempts + 1)
+			r.l.Warn("failed to publish event", "eventId", event.ID, "attempts", event.Attempts+1, "retryIn", delay, "err", err)
+
+			if appErr := r.eventRepo.MarkFailed(ctx, event.ID, err, delay); appErr != nil {
+				return published, appErr
+			}
+			continue
+		}
+
+		if appErr := r.eventRepo.MarkPublished(ctx, event.ID); appErr != nil {
+			return published, appErr
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// backoff doubles the retry delay with every failed attempt, bounded by MaxBackoff.
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.cfg.MinBackoff
+	for i := 1; i < attempts && delay < r.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, r.cfg.MaxBackoff)
+}
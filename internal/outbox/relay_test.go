@@ -0,0 +1,195 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xm/common"
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/google/uuid"
+)
+
+// fakeEventRepository keeps pending events in memory and mimics the outbox bookkeeping
+// of the Postgres implementation, ignoring leases and retry delays.
+type fakeEventRepository struct {
+	pending []domain.Event
+	retries map[uuid.UUID]time.Duration
+}
+
+func (r *fakeEventRepository) StoreEvent(_ context.Context, _ *sql.Tx, event *domain.CloudEvent) common.AppError {
+	data, _ := json.Marshal(event)
+	r.pending = append(r.pending, domain.Event{ID: event.ID, AggregateID: event.AggregateID, EventType: event.Type, Data: data})
+	return nil
+}
+
+func (r *fakeEventRepository) ListByAggregate(_ context.Context, _ domain.EventFilter) (*domain.EventPage, common.AppError) {
+	return &domain.EventPage{}, nil
+}
+
+// ClaimPending returns the oldest pending event of each aggregate, up to limit.
+func (r *fakeEventRepository) ClaimPending(_ context.Context, limit int, _ time.Duration) ([]domain.Event, common.AppError) {
+	var claimed []domain.Event
+	seen := make(map[uuid.UUID]bool)
+
+	for _, event := range r.pending {
+		if len(claimed) == limit {
+			break
+		}
+
+		if !seen[event.AggregateID] {
+			seen[event.AggregateID] = true
+			claimed = append(claimed, event)
+		}
+	}
+
+	return claimed, nil
+}
+
+func (r *fakeEventRepository) MarkPublished(_ context.Context, id uuid.UUID) common.AppError {
+	for i, event := range r.pending {
+		if event.ID == id {
+			r.pending = append(r.pending[:i], r.pending[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *fakeEventRepository) MarkFailed(_ context.Context, id uuid.UUID, _ error, retryIn time.Duration) common.AppError {
+	for i := range r.pending {
+		if r.pending[i].ID == id {
+			r.pending[i].Attempts++
+			r.retries[id] = retryIn
+		}
+	}
+	return nil
+}
+
+func newTestRelay(repo domain.EventRepository, publisher EventPublisher) *Relay {
+	return NewRelay(repo, publisher, RelayConfig{
+		BatchSize:  2,
+		MinBackoff: time.Second,
+		MaxBackoff: 10 * time.Second,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestRelay_RunOnce(t *testing.T) {
+	repo := &fakeEventRepository{retries: make(map[uuid.UUID]time.Duration)}
+	for _, eventType := range []string{domain.EventCompanyCreated, domain.EventCompanyUpdated, domain.EventCompanyDeleted} {
+		event, err := domain.NewCloudEvent(context.Background(), eventType, uuid.New(), struct{}{})
+		if err != nil {
+			t.Fatalf("NewCloudEvent() unexpected error = %v", err)
+		}
+		repo.StoreEvent(context.Background(), nil, event)
+	}
+
+	publisher := NewMemoryPublisher()
+	relay := newTestRelay(repo, publisher)
+
+	published, err := relay.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce() unexpected error = %v", err)
+	}
+	if published != 2 {
+		t.Errorf("RunOnce() published = %v, want 2", published)
+	}
+
+	publisher.FailWith(errors.New("broker unavailable"))
+	if published, _ = relay.RunOnce(context.Background()); published != 0 {
+		t.Errorf("RunOnce() with failing publisher published = %v, want 0", published)
+	}
+	if len(repo.pending) != 1 || repo.retries[repo.pending[0].ID] != time.Second {
+		t.Errorf("RunOnce() failed event not rescheduled, pending = %v, retries = %v", repo.pending, repo.retries)
+	}
+
+	publisher.FailWith(nil)
+	if published, _ = relay.RunOnce(context.Background()); published != 1 {
+		t.Errorf("RunOnce() after recovery published = %v, want 1", published)
+	}
+
+	events := publisher.Events()
+	if len(events) != 3 || events[2].EventType != "company_deleted" {
+		t.Errorf("MemoryPublisher.Events() got = %v, want all three events in order", events)
+	}
+}
+
+func TestRelay_RunOnceKeepsAggregateOrder(t *testing.T) {
+	repo := &fakeEventRepository{retries: make(map[uuid.UUID]time.Duration)}
+	companyID := uuid.New()
+	for _, eventType := range []string{domain.EventCompanyCreated, domain.EventCompanyUpdated} {
+		event, err := domain.NewCloudEvent(context.Background(), eventType, companyID, struct{}{})
+		if err != nil {
+			t.Fatalf("NewCloudEvent() unexpected error = %v", err)
+		}
+		repo.StoreEvent(context.Background(), nil, event)
+	}
+
+	publisher := NewMemoryPublisher()
+	relay := newTestRelay(repo, publisher)
+
+	publisher.FailWith(errors.New("broker unavailable"))
+	relay.RunOnce(context.Background())
+	publisher.FailWith(nil)
+
+	if len(repo.pending) != 2 || repo.pending[0].Attempts != 1 || repo.pending[1].Attempts != 0 {
+		t.Fatalf("RunOnce() with failing publisher got pending = %v, want only the first event attempted", repo.pending)
+	}
+
+	for range 2 {
+		if published, _ := relay.RunOnce(context.Background()); published != 1 {
+			t.Errorf("RunOnce() published = %v, want one event of the company per batch", published)
+		}
+	}
+
+	events := publisher.Events()
+	if len(events) != 2 || events[0].EventType != domain.EventCompanyCreated || events[1].EventType != domain.EventCompanyUpdated {
+		t.Errorf("MemoryPublisher.Events() got = %v, want the company's events in order", events)
+	}
+}
+
+func TestRelay_Backoff(t *testing.T) {
+	relay := newTestRelay(&fakeEventRepository{}, NewMemoryPublisher())
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := relay.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) got = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestRelay_RunStopsOnCancel(t *testing.T) {
+	relay := newTestRelay(&fakeEventRepository{retries: make(map[uuid.UUID]time.Duration)}, NewMemoryPublisher())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		relay.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not return after context cancellation")
+	}
+}
@@ -0,0 +1,91 @@
+package purge
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xm/internal/domain"
+)
+
+// PurgerConfig holds the retention policy and scheduling of a CompanyPurger.
+type PurgerConfig struct {
+	Retention time.Duration
+	Interval  time.Duration
+	BatchSize int
+}
+
+// CompanyPurger permanently removes companies that have been soft-deleted for longer than
+// the retention period. Each removal produces a company_purged event through the outbox.
+type CompanyPurger struct {
+	companyRepo domain.CompanyRepository
+	cfg         PurgerConfig
+	now         func() time.Time
+	l           *slog.Logger
+}
+
+// NewCompanyPurger creates a CompanyPurger, filling unset scheduling values with defaults.
+func NewCompanyPurger(companyRepo domain.CompanyRepository, cfg PurgerConfig, l *slog.Logger) *CompanyPurger {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	return &CompanyPurger{
+		companyRepo: companyRepo,
+		cfg:         cfg,
+		now:         time.Now,
+		l:           l,
+	}
+}
+
+// Run purges expired companies every Interval until ctx is canceled.
+func (p *CompanyPurger) Run(ctx context.Context) {
+	p.l.Info("company purger started", "retention", p.cfg.Retention, "interval", p.cfg.Interval)
+	defer p.l.Info("company purger stopped")
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if _, err := p.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			p.l.Error("company purge failed", "err", err)
+		}
+
+		timer.Reset(p.cfg.Interval)
+	}
+}
+
+// RunOnce purges all companies deleted before now minus Retention, one batch per transaction,
+// and returns how many were removed. Companies purged by earlier batches stay purged on error.
+func (p *CompanyPurger) RunOnce(ctx context.Context) (int, error) {
+	deletedBefore := p.now().Add(-p.cfg.Retention)
+
+	total := 0
+	for {
+		purged, appErr := p.companyRepo.PurgeDeleted(ctx, deletedBefore, p.cfg.BatchSize)
+		if appErr != nil {
+			return total, appErr
+		}
+
+		total += purged
+		if purged < p.cfg.BatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		p.l.Info("purged deleted companies", "count", total, "deletedBefore", deletedBefore)
+	}
+
+	return total, nil
+}
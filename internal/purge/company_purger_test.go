@@ -0,0 +1,96 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xm/common"
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/google/uuid"
+)
+
+// fakeCompanyRepository keeps the deletion time of soft-deleted companies in memory.
+// Only PurgeDeleted is implemented; other methods panic through the nil embedded interface.
+type fakeCompanyRepository struct {
+	domain.CompanyRepository
+	deleted map[uuid.UUID]time.Time
+	calls   int
+	failAt  int
+}
+
+func (r *fakeCompanyRepository) PurgeDeleted(_ context.Context, deletedBefore time.Time, limit int) (int, common.AppError) {
+	r.calls++
+	if r.calls == r.failAt {
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, errors.New("connection reset"))
+	}
+
+	purged := 0
+	for id, deletedAt := range r.deleted {
+		if purged == limit {
+			break
+		}
+		if deletedAt.Before(deletedBefore) {
+			delete(r.deleted, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func newTestPurger(repo domain.CompanyRepository, now time.Time) *CompanyPurger {
+	p := NewCompanyPurger(repo, PurgerConfig{Retention: 30 * 24 * time.Hour, BatchSize: 2},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	p.now = func() time.Time { return now }
+	return p
+}
+
+func TestCompanyPurger_RunOnce(t *testing.T) {
+	now := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	repo := &fakeCompanyRepository{deleted: make(map[uuid.UUID]time.Time)}
+
+	for range 5 {
+		repo.deleted[uuid.New()] = now.AddDate(0, 0, -31)
+	}
+	recent := uuid.New()
+	repo.deleted[recent] = now.AddDate(0, 0, -29)
+
+	purged, err := newTestPurger(repo, now).RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce() unexpected error = %v", err)
+	}
+
+	if purged != 5 {
+		t.Errorf("RunOnce() purged = %v, want 5", purged)
+	}
+
+	if _, ok := repo.deleted[recent]; !ok || len(repo.deleted) != 1 {
+		t.Errorf("RunOnce() should keep companies deleted within the retention period, left = %v", repo.deleted)
+	}
+
+	if repo.calls != 3 {
+		t.Errorf("RunOnce() PurgeDeleted calls = %v, want 3", repo.calls)
+	}
+}
+
+func TestCompanyPurger_RunOnceError(t *testing.T) {
+	now := time.Now()
+	repo := &fakeCompanyRepository{deleted: make(map[uuid.UUID]time.Time), failAt: 2}
+
+	for range 3 {
+		repo.deleted[uuid.New()] = now.AddDate(0, -2, 0)
+	}
+
+	purged, err := newTestPurger(repo, now).RunOnce(context.Background())
+	if err == nil {
+		t.Fatalf("RunOnce() error = nil, expected an error")
+	}
+
+	if purged != 2 {
+		t.Errorf("RunOnce() purged = %v, want 2 from the batch before the failure", purged)
+	}
+}
@@ -0,0 +1,63 @@
+package purge
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xm/common"
+)
+
+// ExpiredDeleter removes records past their expiry and returns how many were removed.
+type ExpiredDeleter interface {
+	DeleteExpired(ctx context.Context) (int64, common.AppError)
+}
+
+// ExpiredPurger periodically deletes expired records, such as idempotency keys with their stored
+// responses or refresh tokens. name describes the records in log messages.
+type ExpiredPurger struct {
+	name     string
+	repo     ExpiredDeleter
+	interval time.Duration
+	l        *slog.Logger
+}
+
+// NewExpiredPurger creates an ExpiredPurger running every interval, hourly by default.
+func NewExpiredPurger(name string, repo ExpiredDeleter, interval time.Duration, l *slog.Logger) *ExpiredPurger {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &ExpiredPurger{
+		name:     name,
+		repo:     repo,
+		interval: interval,
+		l:        l,
+	}
+}
+
+// Run deletes expired records every interval until ctx is canceled.
+func (p *ExpiredPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, appErr := p.repo.DeleteExpired(ctx)
+		if appErr != nil {
+			if ctx.Err() == nil {
+				p.l.Error("purge of expired records failed", "records", p.name, "err", appErr.DetailedError())
+			}
+			continue
+		}
+
+		if deleted > 0 {
+			p.l.Info("purged expired records", "records", p.name, "count", deleted)
+		}
+	}
+}
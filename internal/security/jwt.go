@@ -7,17 +7,20 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // JWTManager handles JWT operations including token generation and validation.
+// RefreshExp is the lifetime of the opaque refresh tokens issued alongside access tokens.
 type JWTManager struct {
 	AccessExp  time.Duration
+	RefreshExp time.Duration
 	PrivateKey *ecdsa.PrivateKey
 	PublicKey  *ecdsa.PublicKey
 }
 
-func NewJWTManager(accessDuration time.Duration, privateKeyPEM, publicKeyPEM []byte) (*JWTManager, error) {
-	if accessDuration <= 0 {
+func NewJWTManager(accessDuration, refreshDuration time.Duration, privateKeyPEM, publicKeyPEM []byte) (*JWTManager, error) {
+	if accessDuration <= 0 || refreshDuration <= 0 {
 		return nil, errors.New("durations must be positive")
 	}
 
@@ -35,24 +38,29 @@ func NewJWTManager(accessDuration time.Duration, privateKeyPEM, publicKeyPEM []b
 		PrivateKey: privateKey,
 		PublicKey:  publicKey,
 		AccessExp:  accessDuration,
+		RefreshExp: refreshDuration,
 	}, nil
 }
 
-// JWTClaims extends standard JWT claims with a UserID for user identification.
+// JWTClaims extends standard JWT claims with a UserID for user identification and the user's Role.
+// Every token carries a unique ID (the jti claim, RegisteredClaims.ID) so it can be revoked individually.
 type JWTClaims struct {
 	UserID string `json:"userId"`
+	Role   string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateAccessToken creates a single JWT access token with ES256 signing method.
-func (jm *JWTManager) GenerateAccessToken(userID string, expiration time.Duration) (string, error) {
+// GenerateAccessToken creates a single JWT access token with ES256 signing method and a random UUID as jti.
+func (jm *JWTManager) GenerateAccessToken(userID, role string, expiration time.Duration) (string, error) {
 	if userID == "" {
 		return "", errors.New("userID cannot be empty")
 	}
 
 	claims := JWTClaims{
 		UserID: userID,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -60,7 +60,7 @@ func TestNewJWTManager(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			jwtManager, err := NewJWTManager(time.Millisecond*100, tt.privateKey, tt.publicKey)
+			jwtManager, err := NewJWTManager(time.Millisecond*100, time.Hour, tt.privateKey, tt.publicKey)
 			if tt.expectError && err == nil {
 				t.Errorf("NewJWTManager() error = nil, expected an error")
 			}
@@ -76,7 +76,7 @@ func TestNewJWTManager(t *testing.T) {
 
 func TestJWTManager_GenerateAccessToken(t *testing.T) {
 	privateKey, publicKey := generateTestKeys(t)
-	jwtManager, err := NewJWTManager(time.Millisecond*100, privateKey, publicKey)
+	jwtManager, err := NewJWTManager(time.Millisecond*100, time.Hour, privateKey, publicKey)
 	if err != nil {
 		t.Errorf("Failed to create JWTManager: %v", err)
 		return
@@ -93,7 +93,7 @@ func TestJWTManager_GenerateAccessToken(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			accessToken, err := jwtManager.GenerateAccessToken(tt.userID, jwtManager.AccessExp)
+			accessToken, err := jwtManager.GenerateAccessToken(tt.userID, "viewer", jwtManager.AccessExp)
 			if tt.expectError && err == nil {
 				t.Errorf("GenerateAccessToken() error = nil, expected an error")
 			}
@@ -109,13 +109,13 @@ func TestJWTManager_GenerateAccessToken(t *testing.T) {
 
 func TestJWTManager_ValidateToken(t *testing.T) {
 	privateKey, publicKey := generateTestKeys(t)
-	jwtManager, err := NewJWTManager(time.Second, privateKey, publicKey)
+	jwtManager, err := NewJWTManager(time.Second, time.Hour, privateKey, publicKey)
 	if err != nil {
 		t.Errorf("Failed to create JWTManager: %v", err)
 		return
 	}
 
-	validToken, err := jwtManager.GenerateAccessToken("user123", time.Second*2)
+	validToken, err := jwtManager.GenerateAccessToken("user123", "editor", time.Second*2)
 	if err != nil {
 		t.Errorf("Failed to generate valid token: %v", err)
 		return
@@ -147,15 +147,45 @@ func TestJWTManager_ValidateToken(t *testing.T) {
 					t.Errorf("ValidateToken() returned nil claims")
 				} else if claims.UserID != tt.expectedID {
 					t.Errorf("ValidateToken() got UserID = %v, want %v", claims.UserID, tt.expectedID)
+				} else if claims.ID == "" {
+					t.Errorf("ValidateToken() got empty token ID")
+				} else if claims.Role != "editor" {
+					t.Errorf("ValidateToken() got Role = %v, want %v", claims.Role, "editor")
 				}
 			}
 		})
 	}
 }
 
+func TestJWTManager_UniqueTokenIDs(t *testing.T) {
+	privateKey, publicKey := generateTestKeys(t)
+	jwtManager, err := NewJWTManager(time.Second, time.Hour, privateKey, publicKey)
+	if err != nil {
+		t.Fatalf("Failed to create JWTManager: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		token, err := jwtManager.GenerateAccessToken("user123", "viewer", jwtManager.AccessExp)
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+
+		claims, err := jwtManager.ValidateToken(token)
+		if err != nil {
+			t.Fatalf("Failed to validate token: %v", err)
+		}
+
+		if seen[claims.ID] {
+			t.Errorf("GenerateAccessToken() reused token ID %v", claims.ID)
+		}
+		seen[claims.ID] = true
+	}
+}
+
 func TestJWTManager_TokenExpiration(t *testing.T) {
 	privateKey, publicKey := generateTestKeys(t)
-	jwtManager, err := NewJWTManager(time.Millisecond*100, privateKey, publicKey)
+	jwtManager, err := NewJWTManager(time.Millisecond*100, time.Hour, privateKey, publicKey)
 	if err != nil {
 		t.Errorf("Failed to create JWTManager: %v", err)
 		return
@@ -163,7 +193,7 @@ func TestJWTManager_TokenExpiration(t *testing.T) {
 
 	veryShortDuration := time.Millisecond * 10
 
-	token, err := jwtManager.GenerateAccessToken("user123", veryShortDuration)
+	token, err := jwtManager.GenerateAccessToken("user123", "viewer", veryShortDuration)
 	if err != nil {
 		t.Fatalf("Failed to generate token: %v", err)
 	}
@@ -183,14 +213,14 @@ func FuzzJWTManager_GenerateAndValidateToken(f *testing.F) {
 	f.Add(strings.Repeat("a", 1000))
 
 	privateKey, publicKey := generateTestKeys(f)
-	jwtManager, err := NewJWTManager(time.Millisecond*100, privateKey, publicKey)
+	jwtManager, err := NewJWTManager(time.Millisecond*100, time.Hour, privateKey, publicKey)
 	if err != nil {
 		f.Errorf("Failed to create JWTManager: %v", err)
 		return
 	}
 
 	f.Fuzz(func(t *testing.T, userID string) {
-		accessToken, err := jwtManager.GenerateAccessToken(userID, jwtManager.AccessExp)
+		accessToken, err := jwtManager.GenerateAccessToken(userID, "viewer", jwtManager.AccessExp)
 		if err != nil {
 			return // Skip invalid inputs
 		}
@@ -0,0 +1,31 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// refreshTokenBytes is the amount of randomness in a refresh token (256 bits).
+const refreshTokenBytes = 32
+
+// GenerateRefreshToken creates an opaque, URL-safe refresh token.
+// Returns the token for the client and its hash for storage; the token itself is never persisted.
+func GenerateRefreshToken() (token string, hash string, err error) {
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the hex-encoded SHA-256 hash of a refresh token.
+// A fast hash is sufficient because tokens are high-entropy random values, unlike passwords.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,27 @@
+package security
+
+import "testing"
+
+func TestGenerateRefreshToken(t *testing.T) {
+	token, hash, err := GenerateRefreshToken()
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken() unexpected error = %v", err)
+	}
+
+	if token == "" || len(hash) != 64 {
+		t.Errorf("GenerateRefreshToken() got token = %q, hash = %q", token, hash)
+	}
+
+	if HashRefreshToken(token) != hash {
+		t.Errorf("HashRefreshToken() does not match the generated hash")
+	}
+
+	other, otherHash, err := GenerateRefreshToken()
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken() unexpected error = %v", err)
+	}
+
+	if other == token || otherHash == hash {
+		t.Errorf("GenerateRefreshToken() returned the same token twice")
+	}
+}
@@ -0,0 +1,505 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/ashtishad/xm/common"
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// errNoUpdates is returned for update requests without any field to change.
+const errNoUpdates = "at least one field must be updated"
+
+// ListAddresses godoc
+// @Summary List the addresses of a company
+// @Description Returns the registered and operating addresses of a company, oldest first.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Success 200 {object} ListAddressesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/addresses [get]
+func (h *CompanyHandler) ListAddresses(c *gin.Context) {
+	id, ok := h.activeCompanyID(c)
+	if !ok {
+		return
+	}
+
+	addresses, appErr := h.detailRepo.ListAddresses(c.Request.Context(), id)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", companyCacheControl)
+	c.JSON(http.StatusOK, ListAddressesResponse{Addresses: addresses})
+}
+
+// CreateAddress godoc
+// @Summary Add an address to a company
+// @Description Adds a registered or operating address to a company. A company has at most one registered address.
+// @Description Emits a company_address_added event.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param input body CreateAddressRequest true "Address details"
+// @Success 201 {object} domain.CompanyAddress
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/addresses [post]
+func (h *CompanyHandler) CreateAddress(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return
+	}
+
+	var req CreateAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	if !h.authorizeManage(c, id) {
+		return
+	}
+
+	address, appErr := h.detailRepo.CreateAddress(c.Request.Context(), &domain.CompanyAddress{
+		ID:         uuid.New(),
+		CompanyID:  id,
+		Kind:       req.Kind,
+		Line1:      req.Line1,
+		Line2:      req.Line2,
+		City:       req.City,
+		PostalCode: req.PostalCode,
+		Region:     req.Region,
+		Country:    req.Country,
+	})
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, address)
+}
+
+// UpdateAddress godoc
+// @Summary Update an address of a company
+// @Description Updates the provided fields of an address. Emits a company_address_updated event.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param addressId path string true "Address ID"
+// @Param input body UpdateAddressRequest true "Address update details"
+// @Success 200 {object} domain.CompanyAddress
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/addresses/{addressId} [patch]
+func (h *CompanyHandler) UpdateAddress(c *gin.Context) {
+	id, addressID, ok := parseDetailIDs(c, "addressId", "Invalid address ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	updates := addressUpdates(req)
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: errNoUpdates})
+		return
+	}
+
+	if !h.authorizeManage(c, id) {
+		return
+	}
+
+	address, appErr := h.detailRepo.UpdateAddress(c.Request.Context(), id, addressID, updates)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// DeleteAddress godoc
+// @Summary Remove an address of a company
+// @Description Removes an address of a company. Emits a company_address_removed event.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param addressId path string true "Address ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/addresses/{addressId} [delete]
+func (h *CompanyHandler) DeleteAddress(c *gin.Context) {
+	id, addressID, ok := parseDetailIDs(c, "addressId", "Invalid address ID")
+	if !ok {
+		return
+	}
+
+	if !h.authorizeManage(c, id) {
+		return
+	}
+
+	if appErr := h.detailRepo.DeleteAddress(c.Request.Context(), id, addressID); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListContacts godoc
+// @Summary List the contacts of a company
+// @Description Returns the contact people of a company, oldest first.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Success 200 {object} ListContactsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/contacts [get]
+func (h *CompanyHandler) ListContacts(c *gin.Context) {
+	id, ok := h.activeCompanyID(c)
+	if !ok {
+		return
+	}
+
+	contacts, appErr := h.detailRepo.ListContacts(c.Request.Context(), id)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", companyCacheControl)
+	c.JSON(http.StatusOK, ListContactsResponse{Contacts: contacts})
+}
+
+// CreateContact godoc
+// @Summary Add a contact to a company
+// @Description Adds a contact person to a company. Emits a company_contact_added event.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param input body CreateContactRequest true "Contact details"
+// @Success 201 {object} domain.CompanyContact
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/contacts [post]
+func (h *CompanyHandler) CreateContact(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return
+	}
+
+	var req CreateContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	if !h.authorizeManage(c, id) {
+		return
+	}
+
+	contact, appErr := h.detailRepo.CreateContact(c.Request.Context(), &domain.CompanyContact{
+		ID:        uuid.New(),
+		CompanyID: id,
+		Name:      req.Name,
+		Role:      req.Role,
+		Email:     req.Email,
+		Phone:     req.Phone,
+	})
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, contact)
+}
+
+// UpdateContact godoc
+// @Summary Update a contact of a company
+// @Description Updates the provided fields of a contact person. Emits a company_contact_updated event.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param contactId path string true "Contact ID"
+// @Param input body UpdateContactRequest true "Contact update details"
+// @Success 200 {object} domain.CompanyContact
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/contacts/{contactId} [patch]
+func (h *CompanyHandler) UpdateContact(c *gin.Context) {
+	id, contactID, ok := parseDetailIDs(c, "contactId", "Invalid contact ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	updates := contactUpdates(req)
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: errNoUpdates})
+		return
+	}
+
+	if !h.authorizeManage(c, id) {
+		return
+	}
+
+	contact, appErr := h.detailRepo.UpdateContact(c.Request.Context(), id, contactID, updates)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, contact)
+}
+
+// DeleteContact godoc
+// @Summary Remove a contact of a company
+// @Description Removes a contact person of a company. Emits a company_contact_removed event.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param contactId path string true "Contact ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/contacts/{contactId} [delete]
+func (h *CompanyHandler) DeleteContact(c *gin.Context) {
+	id, contactID, ok := parseDetailIDs(c, "contactId", "Invalid contact ID")
+	if !ok {
+		return
+	}
+
+	if !h.authorizeManage(c, id) {
+		return
+	}
+
+	if appErr := h.detailRepo.DeleteContact(c.Request.Context(), id, contactID); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListIdentifiers godoc
+// @Summary List the registration identifiers of a company
+// @Description Returns the VAT numbers and LEIs of a company, ordered by scheme.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Success 200 {object} ListIdentifiersResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/identifiers [get]
+func (h *CompanyHandler) ListIdentifiers(c *gin.Context) {
+	id, ok := h.activeCompanyID(c)
+	if !ok {
+		return
+	}
+
+	identifiers, appErr := h.detailRepo.ListIdentifiers(c.Request.Context(), id)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", companyCacheControl)
+	c.JSON(http.StatusOK, ListIdentifiersResponse{Identifiers: identifiers})
+}
+
+// CreateIdentifier godoc
+// @Summary Add a registration identifier to a company
+// @Description Adds a VAT number or an LEI to a company, normalized by stripping spaces, dots and dashes
+// @Description and upper casing letters. LEI check digits are verified. An identifier belongs to a single
+// @Description company, and can't be changed: remove it and add the new value instead.
+// @Description Emits a company_identifier_added event.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param input body CreateIdentifierRequest true "Identifier"
+// @Success 201 {object} domain.CompanyIdentifier
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/identifiers [post]
+func (h *CompanyHandler) CreateIdentifier(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return
+	}
+
+	var req CreateIdentifierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	value := domain.NormalizeIdentifier(req.Value)
+	if err := domain.ValidateIdentifier(req.Scheme, value); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if !h.authorizeManage(c, id) {
+		return
+	}
+
+	identifier, appErr := h.detailRepo.CreateIdentifier(c.Request.Context(), &domain.CompanyIdentifier{
+		ID:        uuid.New(),
+		CompanyID: id,
+		Scheme:    req.Scheme,
+		Value:     value,
+	})
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, identifier)
+}
+
+// DeleteIdentifier godoc
+// @Summary Remove a registration identifier of a company
+// @Description Removes a VAT number or an LEI of a company. Emits a company_identifier_removed event.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param identifierId path string true "Identifier ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/identifiers/{identifierId} [delete]
+func (h *CompanyHandler) DeleteIdentifier(c *gin.Context) {
+	id, identifierID, ok := parseDetailIDs(c, "identifierId", "Invalid identifier ID")
+	if !ok {
+		return
+	}
+
+	if !h.authorizeManage(c, id) {
+		return
+	}
+
+	if appErr := h.detailRepo.DeleteIdentifier(c.Request.Context(), id, identifierID); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// activeCompanyID parses the company ID of the request and checks that the company exists and
+// isn't deleted, writing the error response and returning false otherwise.
+func (h *CompanyHandler) activeCompanyID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return uuid.Nil, false
+	}
+
+	if _, appErr := h.companyRepo.FindByID(c.Request.Context(), id); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+// parseDetailIDs parses the company ID and the ID of one of its details from the request path,
+// writing a 400 response with invalidMessage and returning false when the latter is invalid.
+func parseDetailIDs(c *gin.Context, param string, invalidMessage string) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	detailID, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: invalidMessage})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return id, detailID, true
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ashtishad/xm/internal/domain"
+)
+
+// Formats of the company export.
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+)
+
+// companyExportColumns are the CSV columns of an export, named after the JSON fields of domain.Company.
+var companyExportColumns = []string{
+	"id", "name", "description", "amountOfEmployees", "registered", "status", "type",
+	"ownerId", "parentId", "version", "createdAt", "updatedAt", "deletedAt",
+}
+
+// companyExporter streams companies to a response in CSV or NDJSON.
+// The status and headers are only written along with the first company, or by Close for an
+// empty export, so a failure before the first company can still get an error response.
+type companyExporter struct {
+	w       http.ResponseWriter
+	format  string
+	csv     *csv.Writer
+	json    *json.Encoder
+	started bool
+}
+
+func newCompanyExporter(w http.ResponseWriter, format string) *companyExporter {
+	exporter := &companyExporter{w: w, format: format}
+	if format == exportFormatCSV {
+		exporter.csv = csv.NewWriter(w)
+	} else {
+		exporter.json = json.NewEncoder(w)
+	}
+
+	return exporter
+}
+
+// Started reports whether the response has been started, after which errors can no longer be reported.
+func (e *companyExporter) Started() bool {
+	return e.started
+}
+
+// Write appends a company to the export.
+func (e *companyExporter) Write(company *domain.Company) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	if e.csv != nil {
+		return e.csv.Write(companyCSVRecord(company))
+	}
+
+	return e.json.Encode(company)
+}
+
+// Close completes the export, starting the response of an empty one.
+func (e *companyExporter) Close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	if e.csv != nil {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+
+	return nil
+}
+
+func (e *companyExporter) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+
+	contentType := contentTypeNDJSON
+	if e.csv != nil {
+		contentType = contentTypeCSV + "; charset=utf-8"
+	}
+
+	e.w.Header().Set("Content-Type", contentType)
+	e.w.Header().Set("Content-Disposition", `attachment; filename="companies.`+e.format+`"`)
+	e.w.Header().Set("Cache-Control", "no-store")
+	e.w.WriteHeader(http.StatusOK)
+
+	if e.csv != nil {
+		return e.csv.Write(companyExportColumns)
+	}
+
+	return nil
+}
+
+// companyCSVRecord formats a company as a record of companyExportColumns. Absent values are empty
+// and timestamps are RFC3339.
+func companyCSVRecord(company *domain.Company) []string {
+	var description, ownerID, parentID string
+	if company.Description != nil {
+		description = *company.Description
+	}
+
+	if company.OwnerID != nil {
+		ownerID = company.OwnerID.String()
+	}
+
+	if company.ParentID != nil {
+		parentID = company.ParentID.String()
+	}
+
+	return []string{
+		company.ID.String(),
+		company.Name,
+		description,
+		strconv.Itoa(company.AmountOfEmployees),
+		strconv.FormatBool(company.Registered),
+		company.Status,
+		company.Type,
+		ownerID,
+		parentID,
+		strconv.Itoa(company.Version),
+		formatExportTime(company.CreatedAt),
+		formatExportTime(company.UpdatedAt),
+		formatExportTime(company.DeletedAt),
+	}
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
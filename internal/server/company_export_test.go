@@ -0,0 +1,112 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/google/uuid"
+)
+
+func exportTestCompanies() []*domain.Company {
+	createdAt := time.Date(2024, 9, 27, 16, 59, 2, 0, time.UTC)
+	description := "Makes anvils, and \"rockets\""
+	owner := uuid.MustParse("0b0e5a0c-8f47-4a4e-9d0b-4c9a5f1de2a1")
+	parent := uuid.MustParse("e3f7c0d3-ccb9-4ce4-926f-ffdbd7fdb687")
+
+	return []*domain.Company{
+		{
+			ID: uuid.MustParse("e3f7c0d3-ccb9-4ce4-926f-ffdbd7fdb687"), Name: "Acme", Description: &description,
+			AmountOfEmployees: 12, Registered: true, Status: domain.CompanyStatusRegistered, Type: "NonProfit", OwnerID: &owner,
+			Version: 3, CreatedAt: &createdAt, UpdatedAt: &createdAt,
+		},
+		{
+			ID: uuid.MustParse("f1a2b3c4-0000-4000-8000-000000000001"), Name: "Globex", AmountOfEmployees: 7, ParentID: &parent,
+			Status: domain.CompanyStatusDraft, Type: "Cooperative", Version: 1, CreatedAt: &createdAt, UpdatedAt: &createdAt, DeletedAt: &createdAt,
+		},
+	}
+}
+
+func TestCompanyExporterCSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	exporter := newCompanyExporter(w, exportFormatCSV)
+
+	for _, company := range exportTestCompanies() {
+		if err := exporter.Write(company); err != nil {
+			t.Fatalf("Write() unexpected error = %v", err)
+		}
+	}
+
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("Close() unexpected error = %v", err)
+	}
+
+	want := "id,name,description,amountOfEmployees,registered,status,type,ownerId,parentId,version,createdAt,updatedAt,deletedAt\n" +
+		"e3f7c0d3-ccb9-4ce4-926f-ffdbd7fdb687,Acme,\"Makes anvils, and \"\"rockets\"\"\",12,true,registered,NonProfit,0b0e5a0c-8f47-4a4e-9d0b-4c9a5f1de2a1,,3,2024-09-27T16:59:02Z,2024-09-27T16:59:02Z,\n" +
+		"f1a2b3c4-0000-4000-8000-000000000001,Globex,,7,false,draft,Cooperative,,e3f7c0d3-ccb9-4ce4-926f-ffdbd7fdb687,1,2024-09-27T16:59:02Z,2024-09-27T16:59:02Z,2024-09-27T16:59:02Z\n"
+
+	if got := w.Body.String(); got != want {
+		t.Errorf("CSV export got = %q, want %q", got, want)
+	}
+
+	if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type got = %v, want text/csv; charset=utf-8", got)
+	}
+
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="companies.csv"` {
+		t.Errorf("Content-Disposition got = %v", got)
+	}
+}
+
+func TestCompanyExporterNDJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	exporter := newCompanyExporter(w, exportFormatNDJSON)
+	companies := exportTestCompanies()
+
+	for _, company := range companies {
+		if err := exporter.Write(company); err != nil {
+			t.Fatalf("Write() unexpected error = %v", err)
+		}
+	}
+
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("Close() unexpected error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	if len(lines) != len(companies) {
+		t.Fatalf("NDJSON export got %d lines, want %d", len(lines), len(companies))
+	}
+
+	for i, line := range lines {
+		var company domain.Company
+		if err := json.Unmarshal([]byte(line), &company); err != nil {
+			t.Fatalf("NDJSON line %d unexpected error = %v", i+1, err)
+		}
+
+		if company.ID != companies[i].ID {
+			t.Errorf("NDJSON line %d id = %v, want %v", i+1, company.ID, companies[i].ID)
+		}
+	}
+}
+
+func TestCompanyExporterEmpty(t *testing.T) {
+	w := httptest.NewRecorder()
+	exporter := newCompanyExporter(w, exportFormatCSV)
+
+	if exporter.Started() {
+		t.Errorf("Started() = true before any company, want false")
+	}
+
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("Close() unexpected error = %v", err)
+	}
+
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "id,name,") {
+		t.Errorf("empty CSV export got status %v and body %q, want 200 and the header line", w.Code, w.Body.String())
+	}
+}
@@ -1,40 +1,61 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/ashtishad/xm/common"
 	"github.com/ashtishad/xm/internal/domain"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
 )
 
 type CompanyHandler struct {
 	companyRepo domain.CompanyRepository
+	detailRepo  domain.CompanyDetailRepository
+	typeRepo    domain.CompanyTypeRepository
+	versionRepo domain.CompanyVersionRepository
+	eventRepo   domain.EventRepository
 	l           *slog.Logger
 }
 
-func NewCompanyHandler(companyRepo domain.CompanyRepository, logger *slog.Logger) *CompanyHandler {
+func NewCompanyHandler(companyRepo domain.CompanyRepository, detailRepo domain.CompanyDetailRepository,
+	typeRepo domain.CompanyTypeRepository, versionRepo domain.CompanyVersionRepository, eventRepo domain.EventRepository,
+	logger *slog.Logger) *CompanyHandler {
 	return &CompanyHandler{
 		companyRepo: companyRepo,
+		detailRepo:  detailRepo,
+		typeRepo:    typeRepo,
+		versionRepo: versionRepo,
+		eventRepo:   eventRepo,
 		l:           logger,
 	}
 }
 
 // CreateCompany godoc
 // @Summary Create a new company
-// @Description Creates a new company with the provided details
+// @Description Creates a new company with the provided details, owned by the authenticated user.
+// @Description With parentId, the company is created as a subsidiary of a company the user may modify.
 // @Tags companies
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
+// @Param Idempotency-Key header string false "Key making retries of this request return the original response"
 // @Param input body CreateCompanyRequest true "Company creation details"
 // @Success 201 {object} domain.Company
+// @Header 201 {string} ETag "Version of the company"
+// @Failure 422 {object} ErrorResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Router /companies [post]
 func (h *CompanyHandler) CreateCompany(c *gin.Context) {
 	var req CreateCompanyRequest
@@ -44,37 +65,316 @@ func (h *CompanyHandler) CreateCompany(c *gin.Context) {
 		return
 	}
 
-	company := &domain.Company{
-		ID:                uuid.New(),
-		Name:              req.Name,
-		Description:       req.Description,
-		AmountOfEmployees: req.AmountOfEmployees,
-		Registered:        req.Registered,
-		Type:              req.Type,
+	user, ok := authorizedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Missing authorization"})
+		return
+	}
+
+	if appErr := h.checkCompanyType(c.Request.Context(), req.Type); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if req.ParentID != nil {
+		if appErr := h.authorizeParent(c.Request.Context(), user, *req.ParentID); appErr != nil {
+			c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+			return
+		}
 	}
 
-	createdCompany, appErr := h.companyRepo.Create(c.Request.Context(), company)
+	createdCompany, appErr := h.companyRepo.Create(c.Request.Context(), newCompany(req, user.UUID))
 	if appErr != nil {
 		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
 		return
 	}
 
+	setCompanyCacheHeaders(c, createdCompany)
 	c.JSON(http.StatusCreated, createdCompany)
 }
 
+// ListCompanies godoc
+// @Summary List companies
+// @Description Returns non-deleted companies ordered by ID, using cursor based pagination.
+// @Description Pass the nextCursor of a response as the cursor parameter to fetch the following page.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param cursor query string false "Pagination cursor"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param type query string false "Company type"
+// @Param registered query bool false "Registration status"
+// @Param status query string false "Lifecycle status, e.g. suspended"
+// @Param minEmployees query int false "Minimum amount of employees"
+// @Param maxEmployees query int false "Maximum amount of employees"
+// @Param createdAfter query string false "Created at or after (RFC3339)"
+// @Param createdBefore query string false "Created before (RFC3339)"
+// @Param ownerId query string false "Owner user ID"
+// @Param mine query bool false "Only companies owned by the authenticated user"
+// @Success 200 {object} ListCompaniesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies [get]
+func (h *CompanyHandler) ListCompanies(c *gin.Context) {
+	var req ListCompaniesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	filter, err := buildCompanyFilter(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if req.Mine {
+		user, ok := authorizedUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Missing authorization"})
+			return
+		}
+		filter.OwnerID = &user.UUID
+	}
+
+	page, appErr := h.companyRepo.List(c.Request.Context(), filter)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", companyCacheControl)
+
+	resp := ListCompaniesResponse{Companies: page.Companies}
+	if page.HasMore {
+		resp.NextCursor = encodeCursor(page.Companies[len(page.Companies)-1].ID.String())
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ExportCompanies godoc
+// @Summary Export companies
+// @Description Streams every company matching the filters as CSV or NDJSON, ordered by ID, straight from a
+// @Description database cursor. Takes the filters of List Companies, without pagination.
+// @Description includeDeleted also exports soft-deleted companies and is reserved to admins.
+// @Description A failure after the export has started aborts the connection, so an export is only
+// @Description complete when the response ends normally.
+// @Tags companies
+// @Produce text/csv,application/x-ndjson
+// @Param Authorization header string true "Bearer token"
+// @Param format query string true "Export format (csv or ndjson)"
+// @Param includeDeleted query bool false "Also export soft-deleted companies (admin only)"
+// @Param type query string false "Company type"
+// @Param registered query bool false "Registration status"
+// @Param status query string false "Lifecycle status, e.g. suspended"
+// @Param minEmployees query int false "Minimum amount of employees"
+// @Param maxEmployees query int false "Maximum amount of employees"
+// @Param createdAfter query string false "Created at or after (RFC3339)"
+// @Param createdBefore query string false "Created before (RFC3339)"
+// @Param ownerId query string false "Owner user ID"
+// @Param mine query bool false "Only companies owned by the authenticated user"
+// @Success 200 {string} string "Companies as CSV or NDJSON"
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/export [get]
+func (h *CompanyHandler) ExportCompanies(c *gin.Context) {
+	var req ExportCompaniesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	if req.Cursor != "" || req.Limit != 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "cursor and limit are not supported by exports"})
+		return
+	}
+
+	filter, err := buildCompanyFilter(req.ListCompaniesRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	user, ok := authorizedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Missing authorization"})
+		return
+	}
+
+	if req.Mine {
+		filter.OwnerID = &user.UUID
+	}
+
+	if req.IncludeDeleted {
+		if user.Role != domain.UserRoleAdmin {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "only admins may export deleted companies"})
+			return
+		}
+		filter.IncludeDeleted = true
+	}
+
+	if err := liftDeadlines(c); err != nil {
+		h.l.Warn("failed to lift deadlines of company export", "err", err)
+	}
+
+	exporter := newCompanyExporter(c.Writer, req.Format)
+	appErr := h.companyRepo.Export(c.Request.Context(), filter, exporter.Write)
+	if appErr == nil {
+		if err := exporter.Close(); err != nil {
+			appErr = common.NewInternalServerError("company export aborted", err)
+		}
+	}
+
+	if appErr == nil {
+		return
+	}
+
+	if !exporter.Started() {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	// The 200 status is gone already. Aborting the connection lets the client tell the export
+	// is incomplete, rather than mistaking the rows sent so far for the whole dataset.
+	h.l.Error("company export failed after it started", "err", appErr.DetailedError())
+	panic(http.ErrAbortHandler)
+}
+
+// SearchCompanies godoc
+// @Summary Search companies
+// @Description Searches non-deleted companies by name similarity and by full-text match on their description,
+// @Description best matches first. q follows web search syntax for descriptions: "quoted phrases", or, -excluded.
+// @Description Highlights are HTML-escaped with matches wrapped in <mark> elements.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param q query string true "Search query (2-100 characters)"
+// @Param limit query int false "Maximum amount of results (1-50, default 20)"
+// @Success 200 {object} SearchCompaniesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/search [get]
+func (h *CompanyHandler) SearchCompanies(c *gin.Context) {
+	var req SearchCompaniesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	if req.Limit == 0 {
+		req.Limit = common.DefaultPageLimit
+	}
+
+	results, appErr := h.companyRepo.Search(c.Request.Context(), req.Q, req.Limit)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	resp := SearchCompaniesResponse{Results: make([]CompanySearchHit, 0, len(results))}
+	for _, result := range results {
+		resp.Results = append(resp.Results, CompanySearchHit{
+			Company: result.Company,
+			Score:   result.Score,
+			Highlights: CompanySearchHighlight{
+				Name:        highlightName(result.Company.Name, req.Q),
+				Description: highlightSnippet(result.DescriptionSnippet),
+			},
+		})
+	}
+
+	c.Header("Cache-Control", companyCacheControl)
+	c.JSON(http.StatusOK, resp)
+}
+
+// maxNameSuggestions is the number of available variants suggested for a taken company name.
+const maxNameSuggestions = 3
+
+// CheckNameAvailability godoc
+// @Summary Check company name availability
+// @Description Reports whether a company can be created with the given name. Names are compared
+// @Description case-insensitively against non-deleted companies, like on creation.
+// @Description A taken name comes with up to 3 available variants of at most 15 characters.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param name query string true "Company name (at most 15 characters)"
+// @Success 200 {object} NameAvailabilityResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/name-availability [get]
+func (h *CompanyHandler) CheckNameAvailability(c *gin.Context) {
+	var req NameAvailabilityRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	variants := domain.NameVariants(req.Name)
+
+	taken, appErr := h.companyRepo.TakenNames(c.Request.Context(), append([]string{req.Name}, variants...))
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	resp := NameAvailabilityResponse{Name: req.Name, Available: !slices.Contains(taken, req.Name)}
+	if !resp.Available {
+		for _, variant := range variants {
+			if len(resp.Suggestions) == maxNameSuggestions {
+				break
+			}
+
+			if !slices.Contains(taken, variant) {
+				resp.Suggestions = append(resp.Suggestions, variant)
+			}
+		}
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
+
 // GetCompany godoc
 // @Summary Get a company by ID(UUID)
-// @Description Retrieves a company's details by its ID
+// @Description Retrieves a company's details by its ID. The ETag response header holds the company version,
+// @Description to be sent back as If-Match when updating or deleting the company.
+// @Description Answers 304 Not Modified when If-None-Match or If-Modified-Since show the client's copy is current.
+// @Description With asOf, returns the company as it was at that time instead, without caching headers,
+// @Description and answers 404 if it didn't exist yet or was deleted then.
 // @Tags companies
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
 // @Param id path string true "Company ID"
+// @Param asOf query string false "RFC3339 timestamp to get the company as of"
+// @Param If-None-Match header string false "ETag of the client's copy"
+// @Param If-Modified-Since header string false "Last-Modified of the client's copy"
 // @Success 200 {object} domain.Company
+// @Success 304 "Not Modified"
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Header 200 {string} ETag "Version of the company"
+// @Header 200 {string} Last-Modified "Last update of the company"
 // @Router /companies/{id} [get]
 func (h *CompanyHandler) GetCompany(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
@@ -83,29 +383,67 @@ func (h *CompanyHandler) GetCompany(c *gin.Context) {
 		return
 	}
 
+	var req GetCompanyRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	if req.AsOf != nil {
+		// A past version isn't the current representation, so it gets no ETag or Last-Modified.
+		companyVersion, appErr := h.versionRepo.FindAsOf(c.Request.Context(), id, *req.AsOf)
+		if appErr != nil {
+			c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, companyVersion.Company)
+		return
+	}
+
 	company, appErr := h.companyRepo.FindByID(c.Request.Context(), id)
 	if appErr != nil {
 		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
 		return
 	}
 
+	setCompanyCacheHeaders(c, company)
+
+	var lastModified time.Time
+	if company.UpdatedAt != nil {
+		lastModified = *company.UpdatedAt
+	}
+
+	if isNotModified(c.GetHeader("If-None-Match"), c.GetHeader("If-Modified-Since"), companyETag(company.Version), lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	c.JSON(http.StatusOK, company)
 }
 
 // UpdateCompany godoc
 // @Summary Update a company
-// @Description Updates a company's details
+// @Description Updates a company's details. Only the owner of the company or an admin may update it.
+// @Description The status and registered fields are rejected with 400, they change through Transition Company Status.
+// @Description With If-Match set to the ETag of the company, the update is rejected with 412 when
+// @Description the company has been modified since.
 // @Tags companies
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
 // @Param id path string true "Company ID"
+// @Param If-Match header string false "ETag of the company version being updated"
 // @Param input body UpdateCompanyRequest true "Company update details"
 // @Success 200 {object} domain.Company
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Header 200 {string} ETag "Version of the company"
 // @Router /companies/{id} [patch]
 func (h *CompanyHandler) UpdateCompany(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
@@ -121,62 +459,931 @@ func (h *CompanyHandler) UpdateCompany(c *gin.Context) {
 		return
 	}
 
-	updates := make(map[string]any)
-	if req.Name != nil {
-		updates["name"] = *req.Name
+	if appErr := checkLifecycleUpdate(req); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
 	}
 
-	if req.Description != nil {
-		updates["description"] = *req.Description
+	updates := companyUpdates(req)
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: errNoUpdates})
+		return
 	}
 
-	if req.AmountOfEmployees != nil {
-		updates["amount_of_employees"] = *req.AmountOfEmployees
+	expectedVersion, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusPreconditionFailed, ErrorResponse{Error: err.Error()})
+		return
 	}
 
-	if req.Registered != nil {
-		updates["registered"] = *req.Registered
+	if req.Type != nil {
+		if appErr := h.checkCompanyType(c.Request.Context(), *req.Type); appErr != nil {
+			c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+			return
+		}
 	}
 
-	if req.Type != nil {
-		updates["type"] = *req.Type
+	if !h.authorizeManage(c, id) {
+		return
 	}
 
-	updatedCompany, appErr := h.companyRepo.Update(c.Request.Context(), id, updates)
+	updatedCompany, appErr := h.companyRepo.Update(c.Request.Context(), id, updates, expectedVersion)
 	if appErr != nil {
 		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
 		return
 	}
 
+	setCompanyCacheHeaders(c, updatedCompany)
 	c.JSON(http.StatusOK, updatedCompany)
 }
 
-// DeleteCompany godoc
-// @Summary Delete a company
-// @Description Soft deletes a company by setting its deleted_at timestamp
+// ListCompanyEvents godoc
+// @Summary List the events of a company
+// @Description Returns the event timeline of a company, oldest first, using cursor based pagination.
+// @Description Events of deleted and purged companies remain available.
 // @Tags companies
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
 // @Param id path string true "Company ID"
-// @Success 204 "No Content"
+// @Param cursor query string false "Pagination cursor"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param eventType query string false "Event type, e.g. company_updated"
+// @Success 200 {object} ListCompanyEventsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/events [get]
+func (h *CompanyHandler) ListCompanyEvents(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return
+	}
+
+	var req ListCompanyEventsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	filter, err := buildEventFilter(id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	page, appErr := h.eventRepo.ListByAggregate(c.Request.Context(), filter)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	resp := ListCompanyEventsResponse{Events: page.Events}
+	if page.HasMore {
+		last := page.Events[len(page.Events)-1]
+		resp.NextCursor = encodeCursor(last.CreatedAt.Format(time.RFC3339Nano) + eventCursorSeparator + last.ID.String())
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListCompanyVersions godoc
+// @Summary List the versions of a company
+// @Description Returns the history of a company, one entry per version, oldest first, using cursor based pagination.
+// @Description Every change of the company produces a version, recordedAt tells when. Versions where the company
+// @Description is deleted are included, and the history of purged companies remains available.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param cursor query string false "Pagination cursor"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Success 200 {object} ListCompanyVersionsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/versions [get]
+func (h *CompanyHandler) ListCompanyVersions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return
+	}
+
+	var req ListCompanyVersionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	filter, err := buildCompanyVersionFilter(id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	page, appErr := h.versionRepo.List(c.Request.Context(), filter)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	resp := ListCompanyVersionsResponse{Versions: page.Versions}
+	if page.HasMore {
+		resp.NextCursor = encodeCursor(strconv.Itoa(page.Versions[len(page.Versions)-1].Version))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetCompanyVersion godoc
+// @Summary Get a version of a company
+// @Description Returns a company as it was in the given version, including versions where it is deleted.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param version path int true "Company version"
+// @Success 200 {object} domain.CompanyVersion
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
-// @Router /companies/{id} [delete]
-func (h *CompanyHandler) DeleteCompany(c *gin.Context) {
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/versions/{version} [get]
+func (h *CompanyHandler) GetCompanyVersion(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
 		return
 	}
 
-	appErr := h.companyRepo.Delete(c.Request.Context(), id)
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid version"})
+		return
+	}
+
+	companyVersion, appErr := h.versionRepo.FindByVersion(c.Request.Context(), id, version)
 	if appErr != nil {
 		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusOK, companyVersion)
+}
+
+// DiffCompanyVersions godoc
+// @Summary Compare two versions of a company
+// @Description Returns the fields that differ between two versions of a company, with their value in each.
+// @Description Besides the fields updated through Update Company, the status, owner, parent and deletion time are compared.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param from query int true "Version to compare from"
+// @Param to query int true "Version to compare to"
+// @Success 200 {object} CompanyVersionDiffResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/versions/diff [get]
+func (h *CompanyHandler) DiffCompanyVersions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return
+	}
+
+	var req DiffCompanyVersionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	from, appErr := h.versionRepo.FindByVersion(c.Request.Context(), id, req.From)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	to, appErr := h.versionRepo.FindByVersion(c.Request.Context(), id, req.To)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, CompanyVersionDiffResponse{
+		From:    req.From,
+		To:      req.To,
+		Changes: domain.DiffCompanyVersions(&from.Company, &to.Company),
+	})
+}
+
+// DeleteCompany godoc
+// @Summary Delete a company
+// @Description Soft deletes a company by setting its deleted_at timestamp.
+// @Description Only the owner of the company or an admin may delete it.
+// @Description With If-Match set to the ETag of the company, the delete is rejected with 412 when
+// @Description the company has been modified since.
+// @Description A company with active subsidiaries is rejected with 409, unless cascade deletes them along with it.
+// @Description A cascade is rejected with 403 when the user may not modify one of the subsidiaries.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param If-Match header string false "ETag of the company version being deleted"
+// @Param cascade query bool false "Also delete all active subsidiaries, recursively"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Router /companies/{id} [delete]
+func (h *CompanyHandler) DeleteCompany(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return
+	}
+
+	var req DeleteCompanyRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusPreconditionFailed, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if !h.authorizeManage(c, id) {
+		return
+	}
+
+	if req.Cascade {
+		user, _ := authorizedUser(c)
+		if appErr := h.authorizeCascade(c.Request.Context(), user, id); appErr != nil {
+			c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+			return
+		}
+	}
+
+	appErr := h.companyRepo.Delete(c.Request.Context(), id, expectedVersion, req.Cascade)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TransferOwnership godoc
+// @Summary Transfer ownership of a company
+// @Description Hands a company over to another user. Only the current owner or an admin may transfer it.
+// @Description Emits a company_ownership_transferred event including the previous owner.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param input body TransferOwnershipRequest true "New owner"
+// @Success 200 {object} domain.Company
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/transfer-ownership [post]
+func (h *CompanyHandler) TransferOwnership(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return
+	}
+
+	var req TransferOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	if !h.authorizeManage(c, id) {
+		return
+	}
+
+	company, appErr := h.companyRepo.TransferOwnership(c.Request.Context(), id, uuid.MustParse(req.NewOwnerID))
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	setCompanyCacheHeaders(c, company)
+	c.JSON(http.StatusOK, company)
+}
+
+// TransitionCompanyStatus godoc
+// @Summary Transition the status of a company
+// @Description Moves a company along its lifecycle: draft, pending_registration, registered, suspended, dissolved.
+// @Description Transitions not allowed from the current status are rejected with 409. The registered field
+// @Description follows the status: it is true for registered and suspended companies.
+// @Description With If-Match set to the ETag of the company, the transition is rejected with 412 when
+// @Description the company has been modified since.
+// @Description Emits the event of the transition (company_registration_requested, company_registration_withdrawn,
+// @Description company_registered, company_suspended, company_reinstated or company_dissolved) with the previous
+// @Description status and the reason.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param If-Match header string false "ETag of the company version being changed"
+// @Param input body TransitionCompanyRequest true "New status and reason"
+// @Success 200 {object} domain.Company
+// @Header 200 {string} ETag "Version of the company"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Router /companies/{id}/transition [post]
+func (h *CompanyHandler) TransitionCompanyStatus(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return
+	}
+
+	var req TransitionCompanyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusPreconditionFailed, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if !h.authorizeManage(c, id) {
+		return
+	}
+
+	company, appErr := h.companyRepo.Transition(c.Request.Context(), id, req.Status, req.Reason, expectedVersion)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	setCompanyCacheHeaders(c, company)
+	c.JSON(http.StatusOK, company)
+}
+
+// authorizeParent checks that the user may attach subsidiaries to the parent company, which takes
+// being allowed to manage it.
+func (h *CompanyHandler) authorizeParent(ctx context.Context, user *domain.User, parentID uuid.UUID) common.AppError {
+	parent, appErr := h.companyRepo.FindByID(ctx, parentID)
+	if appErr != nil {
+		if appErr.Code() == http.StatusNotFound {
+			return common.NewNotFoundError("parent company not found")
+		}
+		return appErr
+	}
+
+	if !domain.CanManageCompany(user, parent) {
+		return common.NewForbiddenError("only the owner of the parent company or an admin may add subsidiaries to it")
+	}
+
+	return nil
+}
+
+// authorizeCascade checks that the user may manage every active subsidiary of the company, since a
+// cascade delete removes them all along with it.
+func (h *CompanyHandler) authorizeCascade(ctx context.Context, user *domain.User, id uuid.UUID) common.AppError {
+	subsidiaries, appErr := h.companyRepo.Subsidiaries(ctx, id, true)
+	if appErr != nil {
+		return appErr
+	}
+
+	for _, subsidiary := range subsidiaries {
+		if !domain.CanManageCompany(user, &subsidiary.Company) {
+			return common.NewForbiddenError(fmt.Sprintf("cascade would delete subsidiary %s, which you may not modify", subsidiary.ID))
+		}
+	}
+
+	return nil
+}
+
+// checkCompanyType checks that name is one of the company types, returning a BadRequestError otherwise.
+func (h *CompanyHandler) checkCompanyType(ctx context.Context, name string) common.AppError {
+	exists, appErr := h.typeRepo.Exists(ctx, name)
+	if appErr != nil {
+		return appErr
+	}
+
+	if !exists {
+		return common.NewBadRequestError(fmt.Sprintf("type %q is not a known company type", name))
+	}
+
+	return nil
+}
+
+// errManageForbidden is returned to users who may not modify a company.
+const errManageForbidden = "only the owner of the company or an admin may modify it"
+
+// authorizeManage checks that the authenticated user owns the company or is an admin,
+// writing the error response and returning false otherwise.
+func (h *CompanyHandler) authorizeManage(c *gin.Context, id uuid.UUID) bool {
+	user, ok := authorizedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Missing authorization"})
+		return false
+	}
+
+	company, appErr := h.companyRepo.FindByID(c.Request.Context(), id)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return false
+	}
+
+	if !domain.CanManageCompany(user, company) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: errManageForbidden})
+		return false
+	}
+
+	return true
+}
+
+// RestoreCompany godoc
+// @Summary Restore a deleted company
+// @Description Undoes a soft delete by clearing the company's deleted_at timestamp. Admin only.
+// @Description Rejected when the company isn't deleted or its name has been reused by another company.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Success 200 {object} domain.Company
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/restore [post]
+func (h *CompanyHandler) RestoreCompany(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return
+	}
+
+	company, appErr := h.companyRepo.Restore(c.Request.Context(), id)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	setCompanyCacheHeaders(c, company)
+	c.JSON(http.StatusOK, company)
+}
+
+// BatchCompanies godoc
+// @Summary Apply a batch of company operations
+// @Description Creates, updates and deletes companies in a single transaction, validating each operation
+// @Description like its single company endpoint and emitting one event per affected company.
+// @Description In atomic mode (the default) a failing operation aborts the batch: the response has its status
+// @Description and every other operation reports 424. In partial mode each operation succeeds or fails on its own
+// @Description and the response is 200; every result carries the status of its operation.
+// @Description Operations are authorized before the batch runs, so they can only reference companies that already
+// @Description exist: updating, deleting or creating a subsidiary of a company created by the same batch reports 404.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param Idempotency-Key header string false "Key making retries of this request return the original response"
+// @Param input body BatchCompaniesRequest true "Batch operations"
+// @Success 200 {object} BatchCompaniesResponse
+// @Failure 400 {object} BatchCompaniesResponse
+// @Failure 403 {object} BatchCompaniesResponse
+// @Failure 404 {object} BatchCompaniesResponse
+// @Failure 409 {object} BatchCompaniesResponse
+// @Failure 412 {object} BatchCompaniesResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /companies:batch [post]
+func (h *CompanyHandler) BatchCompanies(c *gin.Context) {
+	var req BatchCompaniesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	user, ok := authorizedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Missing authorization"})
+		return
+	}
+
+	results := make([]BatchCompanyResult, len(req.Operations))
+	ops := make([]domain.CompanyOperation, 0, len(req.Operations))
+	positions := make([]int, 0, len(req.Operations))
+
+	for i, reqOp := range req.Operations {
+		results[i] = BatchCompanyResult{Index: i, Op: reqOp.Op}
+
+		op, appErr := parseBatchOperation(reqOp, user.UUID)
+		if appErr == nil {
+			appErr = h.checkOperationType(c.Request.Context(), op)
+		}
+
+		if appErr == nil {
+			appErr = h.authorizeOperation(c.Request.Context(), user, op)
+		}
+
+		if appErr != nil {
+			results[i].Status = appErr.Code()
+			results[i].Error = appErr.Error()
+			continue
+		}
+
+		ops = append(ops, op)
+		positions = append(positions, i)
+	}
+
+	atomic := req.Mode != batchModePartial
+	if atomic && len(ops) < len(req.Operations) {
+		c.JSON(abortBatch(results), BatchCompaniesResponse{Results: results})
+		return
+	}
+
+	if len(ops) > 0 {
+		opResults, appErr := h.companyRepo.Batch(c.Request.Context(), ops, atomic)
+		if appErr != nil {
+			c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+			return
+		}
+
+		for j, opResult := range opResults {
+			result := &results[positions[j]]
+			switch {
+			case opResult.Err != nil:
+				result.Status = opResult.Err.Code()
+				result.Error = opResult.Err.Error()
+			case opResult.Company == nil:
+				// Not attempted, an earlier operation aborted the batch.
+			case ops[j].Type == domain.CompanyOperationCreate:
+				result.Status = http.StatusCreated
+				result.Company = opResult.Company
+			case ops[j].Type == domain.CompanyOperationUpdate:
+				result.Status = http.StatusOK
+				result.Company = opResult.Company
+			default:
+				result.Status = http.StatusNoContent
+			}
+		}
+	}
+
+	status := http.StatusOK
+	if atomic {
+		status = abortBatch(results)
+	}
+
+	c.JSON(status, BatchCompaniesResponse{Results: results})
+}
+
+// authorizeOperation applies the checks of the single company endpoints to a batch operation:
+// deleting needs the delete permission, updating or deleting needs to be allowed to manage the company,
+// a cascade delete also every subsidiary, and creating a subsidiary needs to be allowed to manage its parent.
+// It runs before the batch, so companies created by earlier operations of the same batch are not found.
+func (h *CompanyHandler) authorizeOperation(ctx context.Context, user *domain.User, op domain.CompanyOperation) common.AppError {
+	if op.Type == domain.CompanyOperationCreate {
+		if op.Company.ParentID != nil {
+			return h.authorizeParent(ctx, user, *op.Company.ParentID)
+		}
+		return nil
+	}
+
+	if op.Type == domain.CompanyOperationDelete && !user.Role.Can(domain.PermissionCompaniesDelete) {
+		return common.NewForbiddenError("missing permission " + string(domain.PermissionCompaniesDelete))
+	}
+
+	company, appErr := h.companyRepo.FindByID(ctx, op.ID)
+	if appErr != nil {
+		return appErr
+	}
+
+	if !domain.CanManageCompany(user, company) {
+		return common.NewForbiddenError(errManageForbidden)
+	}
+
+	if op.Type == domain.CompanyOperationDelete && op.Cascade {
+		return h.authorizeCascade(ctx, user, op.ID)
+	}
+
+	return nil
+}
+
+// checkOperationType checks the company type set by a create or update batch operation.
+func (h *CompanyHandler) checkOperationType(ctx context.Context, op domain.CompanyOperation) common.AppError {
+	switch op.Type {
+	case domain.CompanyOperationCreate:
+		return h.checkCompanyType(ctx, op.Company.Type)
+	case domain.CompanyOperationUpdate:
+		if companyType, ok := op.Updates["type"].(string); ok {
+			return h.checkCompanyType(ctx, companyType)
+		}
+	}
+
+	return nil
+}
+
+// Statuses of the rows of a company import.
+const (
+	importRowCreated   = "created"
+	importRowDuplicate = "duplicate"
+	importRowInvalid   = "invalid"
+)
+
+// ImportCompanies godoc
+// @Summary Import companies from CSV or NDJSON
+// @Description Streams a CSV document (text/csv, with a header line naming the columns name, description,
+// @Description amountOfEmployees, registered and type) or NDJSON (application/x-ndjson, one company per line).
+// @Description Every row is validated like Create Company and created on its own, owned by the authenticated user.
+// @Description The response reports every row as created, duplicate or invalid. When the document is malformed,
+// @Description the import stops with 400 and the report of the rows before.
+// @Tags companies
+// @Accept text/csv,application/x-ndjson
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body string true "Companies as CSV or NDJSON"
+// @Success 200 {object} ImportCompaniesResponse
+// @Failure 400 {object} ImportCompaniesResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ImportCompaniesResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/import [post]
+func (h *CompanyHandler) ImportCompanies(c *gin.Context) {
+	read := companyImportReader(c.ContentType())
+	if read == nil {
+		c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{Error: "Content-Type must be text/csv or application/x-ndjson"})
+		return
+	}
+
+	user, ok := authorizedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Missing authorization"})
+		return
+	}
+
+	if err := liftDeadlines(c); err != nil {
+		h.l.Warn("failed to lift deadlines of company import", "err", err)
+	}
+
+	report := ImportCompaniesResponse{Rows: []ImportCompanyRow{}}
+	var appErr common.AppError
+
+	err := read(c.Request.Body, func(line int, req CreateCompanyRequest, decodeErr error) error {
+		var row ImportCompanyRow
+		row, appErr = h.importCompany(c.Request.Context(), user, req, decodeErr)
+		if appErr != nil {
+			return appErr
+		}
+
+		row.Line = line
+		report.Rows = append(report.Rows, row)
+
+		switch row.Status {
+		case importRowCreated:
+			report.Created++
+		case importRowDuplicate:
+			report.Duplicates++
+		default:
+			report.Invalid++
+		}
+
+		return nil
+	})
+
+	switch {
+	case appErr != nil:
+		report.Error = appErr.Error()
+		c.JSON(appErr.Code(), report)
+	case err != nil:
+		h.l.Error(common.ErrInvalidRequest, "err", err)
+		report.Error = err.Error()
+		c.JSON(http.StatusBadRequest, report)
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// importCompany validates a decoded import row and creates its company. Only unexpected
+// repository errors are returned; duplicates and invalid rows, including rows whose parent company
+// is missing or may not be managed by user, are reported in the row.
+func (h *CompanyHandler) importCompany(ctx context.Context, user *domain.User, req CreateCompanyRequest, decodeErr error) (ImportCompanyRow, common.AppError) {
+	row := ImportCompanyRow{Name: req.Name}
+
+	if decodeErr == nil {
+		decodeErr = binding.Validator.ValidateStruct(&req)
+	}
+
+	if decodeErr != nil {
+		row.Status = importRowInvalid
+		row.Error = formatValidationError(decodeErr)
+		return row, nil
+	}
+
+	appErr := h.checkCompanyType(ctx, req.Type)
+	if appErr == nil && req.ParentID != nil {
+		appErr = h.authorizeParent(ctx, user, *req.ParentID)
+	}
+
+	var company *domain.Company
+	if appErr == nil {
+		company, appErr = h.companyRepo.Create(ctx, newCompany(req, user.UUID))
+	}
+
+	if appErr != nil {
+		switch appErr.Code() {
+		case http.StatusConflict:
+			row.Status = importRowDuplicate
+		case http.StatusBadRequest, http.StatusNotFound, http.StatusForbidden:
+			row.Status = importRowInvalid
+		default:
+			return row, appErr
+		}
+
+		row.Error = appErr.Error()
+		return row, nil
+	}
+
+	row.Status = importRowCreated
+	row.CompanyID = &company.ID
+	return row, nil
+}
+
+// SetCompanyParent godoc
+// @Summary Set the parent of a company
+// @Description Makes a company a subsidiary of another company, or a top-level company with a null parentId.
+// @Description The user must be allowed to modify both the company and its new parent.
+// @Description Rejected with 409 when the parent is the company itself or one of its subsidiaries.
+// @Description Emits a company_parent_changed event including the previous parent.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param input body SetParentRequest true "New parent"
+// @Success 200 {object} domain.Company
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/parent [put]
+func (h *CompanyHandler) SetCompanyParent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return
+	}
+
+	var req SetParentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	if !h.authorizeManage(c, id) {
+		return
+	}
+
+	if req.ParentID != nil {
+		user, _ := authorizedUser(c)
+		if appErr := h.authorizeParent(c.Request.Context(), user, *req.ParentID); appErr != nil {
+			c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+			return
+		}
+	}
+
+	company, appErr := h.companyRepo.SetParent(c.Request.Context(), id, req.ParentID)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	setCompanyCacheHeaders(c, company)
+	c.JSON(http.StatusOK, company)
+}
+
+// ListSubsidiaries godoc
+// @Summary List the subsidiaries of a company
+// @Description Returns the active direct subsidiaries of a company, or all of its active descendants with
+// @Description recursive, ordered by depth then ID. Depth is 1 for direct subsidiaries.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Param recursive query bool false "Include subsidiaries of subsidiaries"
+// @Success 200 {object} RelatedCompaniesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/subsidiaries [get]
+func (h *CompanyHandler) ListSubsidiaries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return
+	}
+
+	var req ListSubsidiariesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	if _, appErr := h.companyRepo.FindByID(c.Request.Context(), id); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	subsidiaries, appErr := h.companyRepo.Subsidiaries(c.Request.Context(), id, req.Recursive)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", companyCacheControl)
+	c.JSON(http.StatusOK, RelatedCompaniesResponse{Companies: subsidiaries})
+}
+
+// ListAncestors godoc
+// @Summary List the ancestors of a company
+// @Description Returns the parent of a company, its parent's parent and so on up to the top of its group,
+// @Description nearest first. Depth is 1 for the parent. Deleted ancestors are included with their deletedAt.
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Company ID"
+// @Success 200 {object} RelatedCompaniesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /companies/{id}/ancestors [get]
+func (h *CompanyHandler) ListAncestors(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid company ID"})
+		return
+	}
+
+	if _, appErr := h.companyRepo.FindByID(c.Request.Context(), id); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	ancestors, appErr := h.companyRepo.Ancestors(c.Request.Context(), id)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", companyCacheControl)
+	c.JSON(http.StatusOK, RelatedCompaniesResponse{Companies: ancestors})
 }
@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ashtishad/xm/common"
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fakeCompanyRepository keeps created and deleted companies in memory, and serves existing companies
+// and their subsidiaries from companies and subsidiaries.
+type fakeCompanyRepository struct {
+	domain.CompanyRepository
+	companies    map[uuid.UUID]*domain.Company
+	subsidiaries []domain.RelatedCompany
+	created      []*domain.Company
+	deleted      []uuid.UUID
+}
+
+func (r *fakeCompanyRepository) Create(_ context.Context, company *domain.Company) (*domain.Company, common.AppError) {
+	r.created = append(r.created, company)
+	return company, nil
+}
+
+func (r *fakeCompanyRepository) FindByID(_ context.Context, id uuid.UUID) (*domain.Company, common.AppError) {
+	company, ok := r.companies[id]
+	if !ok {
+		return nil, common.NewNotFoundError("company not found")
+	}
+	return company, nil
+}
+
+func (r *fakeCompanyRepository) Subsidiaries(_ context.Context, _ uuid.UUID, _ bool) ([]domain.RelatedCompany, common.AppError) {
+	return r.subsidiaries, nil
+}
+
+func (r *fakeCompanyRepository) Delete(_ context.Context, id uuid.UUID, _ *int, _ bool) common.AppError {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+// Batch applies create operations like Create and reports every other operation as applied.
+func (r *fakeCompanyRepository) Batch(ctx context.Context, ops []domain.CompanyOperation, _ bool) ([]domain.CompanyOperationResult, common.AppError) {
+	results := make([]domain.CompanyOperationResult, len(ops))
+	for i, op := range ops {
+		if op.Type == domain.CompanyOperationCreate {
+			results[i].Company, _ = r.Create(ctx, op.Company)
+			continue
+		}
+		results[i].Company = r.companies[op.ID]
+	}
+	return results, nil
+}
+
+// fakeCompanyTypeRepository knows every company type.
+type fakeCompanyTypeRepository struct {
+	domain.CompanyTypeRepository
+}
+
+func (fakeCompanyTypeRepository) Exists(_ context.Context, _ string) (bool, common.AppError) {
+	return true, nil
+}
+
+// testEditor is the user authorized on the requests of newCompanyTestRouter.
+var testEditor = &domain.User{ID: 1, UUID: uuid.New(), Role: domain.UserRoleEditor}
+
+func newCompanyTestRouter(companyRepo domain.CompanyRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewCompanyHandler(companyRepo, nil, fakeCompanyTypeRepository{}, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("authorizedUser", testEditor)
+	})
+	router.POST("/companies", h.CreateCompany)
+	router.PATCH("/companies/:id", h.UpdateCompany)
+	router.DELETE("/companies/:id", h.DeleteCompany)
+	router.POST("/companies:method", customMethods("method", map[string]gin.HandlerFunc{
+		"batch": h.BatchCompanies,
+	}))
+
+	return router
+}
+
+func TestCreateCompanyRegistered(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		wantCode       int
+		wantRegistered bool
+		wantStatus     string
+	}{
+		{"Unregistered", `{"name":"Acme","amountOfEmployees":5,"registered":false,"type":"NonProfit"}`,
+			http.StatusCreated, false, domain.CompanyStatusDraft},
+		{"Registered", `{"name":"Acme","amountOfEmployees":5,"registered":true,"type":"NonProfit"}`,
+			http.StatusCreated, true, domain.CompanyStatusRegistered},
+		{"Registered missing", `{"name":"Acme","amountOfEmployees":5,"type":"NonProfit"}`,
+			http.StatusBadRequest, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeCompanyRepository{}
+			w := httptest.NewRecorder()
+			newCompanyTestRouter(repo).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/companies", strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("CreateCompany() got status = %d, want %d, body %s", w.Code, tt.wantCode, w.Body)
+			}
+
+			if tt.wantCode != http.StatusCreated {
+				if len(repo.created) != 0 {
+					t.Errorf("CreateCompany() created %d companies, want none", len(repo.created))
+				}
+				return
+			}
+
+			var company domain.Company
+			if err := json.Unmarshal(w.Body.Bytes(), &company); err != nil {
+				t.Fatalf("CreateCompany() returned invalid JSON: %v", err)
+			}
+
+			if company.Registered != tt.wantRegistered || company.Status != tt.wantStatus {
+				t.Errorf("CreateCompany() got registered = %v, status = %v, want %v, %v",
+					company.Registered, company.Status, tt.wantRegistered, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestUpdateCompanyWithoutFields(t *testing.T) {
+	for _, body := range []string{`{}`, `{"name":null,"description":null}`} {
+		t.Run(body, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPatch, "/companies/"+uuid.NewString(), strings.NewReader(body))
+			newCompanyTestRouter(&fakeCompanyRepository{}).ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("UpdateCompany() got status = %d, want %d, body %s", w.Code, http.StatusBadRequest, w.Body)
+			}
+		})
+	}
+}
+
+func TestDeleteCompanyCascadeOwnership(t *testing.T) {
+	otherOwner := uuid.New()
+	parent := &domain.Company{ID: uuid.New(), OwnerID: &testEditor.UUID}
+	owned := domain.RelatedCompany{Company: domain.Company{ID: uuid.New(), OwnerID: &testEditor.UUID}, Depth: 1}
+	foreign := domain.RelatedCompany{Company: domain.Company{ID: uuid.New(), OwnerID: &otherOwner}, Depth: 2}
+
+	tests := []struct {
+		name         string
+		subsidiaries []domain.RelatedCompany
+		wantCode     int
+	}{
+		{"Own subsidiaries", []domain.RelatedCompany{owned}, http.StatusNoContent},
+		{"Subsidiary of another owner", []domain.RelatedCompany{owned, foreign}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeCompanyRepository{
+				companies:    map[uuid.UUID]*domain.Company{parent.ID: parent},
+				subsidiaries: tt.subsidiaries,
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/companies/"+parent.ID.String()+"?cascade=true", nil)
+			newCompanyTestRouter(repo).ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("DeleteCompany() got status = %d, want %d, body %s", w.Code, tt.wantCode, w.Body)
+			}
+
+			if wantDeleted := tt.wantCode == http.StatusNoContent; (len(repo.deleted) == 1) != wantDeleted {
+				t.Errorf("DeleteCompany() deleted = %v, want deleted %v", repo.deleted, wantDeleted)
+			}
+		})
+	}
+}
+
+func TestBatchCompaniesReferenceToCreatedCompany(t *testing.T) {
+	// The client can't know the ID of a company created by the batch, so any ID it sends for one is unknown.
+	unknownID := uuid.NewString()
+
+	tests := []struct {
+		name         string
+		mode         string
+		operation    string
+		wantCode     int
+		wantStatuses []int
+	}{
+		{"Update, atomic", "atomic", `{"op":"update","id":"` + unknownID + `","data":{"amountOfEmployees":10}}`,
+			http.StatusNotFound, []int{http.StatusFailedDependency, http.StatusNotFound}},
+		{"Subsidiary, atomic", "atomic", `{"op":"create","data":{"name":"Sub","amountOfEmployees":1,"registered":true,"type":"Corporations","parentId":"` + unknownID + `"}}`,
+			http.StatusNotFound, []int{http.StatusFailedDependency, http.StatusNotFound}},
+		{"Delete, partial", "partial", `{"op":"delete","id":"` + unknownID + `"}`,
+			http.StatusOK, []int{http.StatusCreated, http.StatusNotFound}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeCompanyRepository{}
+			body := `{"mode":"` + tt.mode + `","operations":[` +
+				`{"op":"create","data":{"name":"TechCorp","amountOfEmployees":50,"registered":true,"type":"Corporations"}},` +
+				tt.operation + `]}`
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/companies:batch", strings.NewReader(body))
+			newCompanyTestRouter(repo).ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("BatchCompanies() got status = %d, want %d, body %s", w.Code, tt.wantCode, w.Body)
+			}
+
+			var resp BatchCompaniesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("BatchCompanies() body %s: %v", w.Body, err)
+			}
+
+			if len(resp.Results) != len(tt.wantStatuses) {
+				t.Fatalf("BatchCompanies() got %d results, want %d", len(resp.Results), len(tt.wantStatuses))
+			}
+
+			for i, want := range tt.wantStatuses {
+				if resp.Results[i].Status != want {
+					t.Errorf("BatchCompanies() result %d got status = %d, want %d", i, resp.Results[i].Status, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Content types accepted by the company import.
+const (
+	contentTypeCSV          = "text/csv"
+	contentTypeNDJSON       = "application/x-ndjson"
+	contentTypeNDJSONLegacy = "application/ndjson"
+)
+
+// maxImportLineSize bounds a single NDJSON line, well above the largest valid company.
+const maxImportLineSize = 64 * 1024
+
+// csvImportColumns are the columns a CSV import may have, named after the CreateCompanyRequest JSON fields.
+var csvImportColumns = []string{"name", "description", "amountOfEmployees", "registered", "type", "parentId"}
+
+// csvOptionalImportColumns are the csvImportColumns a CSV import may leave out.
+var csvOptionalImportColumns = []string{"description", "parentId"}
+
+// importRowFunc handles a row of an import found on line of the input. decodeErr is set when the
+// row could not be decoded into req. Returning an error stops the import.
+type importRowFunc func(line int, req CreateCompanyRequest, decodeErr error) error
+
+// companyImportReader returns the reader of the given content type, or nil if it isn't supported.
+func companyImportReader(contentType string) func(io.Reader, importRowFunc) error {
+	switch contentType {
+	case contentTypeCSV:
+		return readCSVCompanies
+	case contentTypeNDJSON, contentTypeNDJSONLegacy:
+		return readNDJSONCompanies
+	default:
+		return nil
+	}
+}
+
+// utf8BOM is the byte order mark spreadsheet applications such as Excel put in front of exported CSV.
+const utf8BOM = "\ufeff"
+
+// readCSVCompanies streams the rows of a CSV document whose first line names its columns.
+// A leading byte order mark is skipped, so it doesn't become part of the first column name.
+// Rows with the wrong number of fields or unparsable values are handed to fn with a decodeErr;
+// an invalid header or malformed CSV stops the import with an error.
+func readCSVCompanies(r io.Reader, fn importRowFunc) error {
+	br := bufio.NewReader(r)
+	if prefix, _ := br.Peek(len(utf8BOM)); string(prefix) == utf8BOM {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+
+	reader := csv.NewReader(br)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return errors.New("missing CSV header")
+		}
+		return fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns, err := csvColumnIndexes(header)
+	if err != nil {
+		return err
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+			if err = fn(parseErr.StartLine, CreateCompanyRequest{}, fmt.Errorf("expected %d fields, got %d", len(header), len(record))); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		req, decodeErr := decodeCSVCompany(record, columns)
+		if err = fn(line, req, decodeErr); err != nil {
+			return err
+		}
+	}
+}
+
+// csvColumnIndexes maps every column of header to its position, rejecting unknown, repeated
+// and missing required columns.
+func csvColumnIndexes(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(csvImportColumns, name) {
+			return nil, fmt.Errorf("unknown CSV column %q, expected %s", name, strings.Join(csvImportColumns, ", "))
+		}
+
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("repeated CSV column %q", name)
+		}
+
+		columns[name] = i
+	}
+
+	for _, name := range csvImportColumns {
+		if _, ok := columns[name]; !ok && !slices.Contains(csvOptionalImportColumns, name) {
+			return nil, fmt.Errorf("missing CSV column %q", name)
+		}
+	}
+
+	return columns, nil
+}
+
+// decodeCSVCompany converts a CSV record into a CreateCompanyRequest. An empty description or parentId is omitted.
+func decodeCSVCompany(record []string, columns map[string]int) (CreateCompanyRequest, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	req := CreateCompanyRequest{Name: field("name"), Type: field("type")}
+
+	if description := field("description"); description != "" {
+		req.Description = &description
+	}
+
+	if employees := field("amountOfEmployees"); employees != "" {
+		amount, err := strconv.Atoi(employees)
+		if err != nil {
+			return req, errors.New("amountOfEmployees must be a whole number")
+		}
+		req.AmountOfEmployees = amount
+	}
+
+	if parent := field("parentId"); parent != "" {
+		parentID, err := uuid.Parse(parent)
+		if err != nil {
+			return req, errors.New("parentId must be a valid UUID")
+		}
+		req.ParentID = &parentID
+	}
+
+	if registered := field("registered"); registered != "" {
+		value, err := strconv.ParseBool(registered)
+		if err != nil {
+			return req, errors.New("registered must be true or false")
+		}
+		req.Registered = &value
+	}
+
+	return req, nil
+}
+
+// readNDJSONCompanies streams a document holding one CreateCompanyRequest JSON object per line.
+// Blank lines are skipped and lines that aren't valid JSON are handed to fn with a decodeErr.
+func readNDJSONCompanies(r io.Reader, fn importRowFunc) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxImportLineSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		data := scanner.Bytes()
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+
+		var req CreateCompanyRequest
+		var decodeErr error
+		if err := json.Unmarshal(data, &req); err != nil {
+			decodeErr = fmt.Errorf("invalid JSON: %w", err)
+		}
+
+		if err := fn(line, req, decodeErr); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("invalid NDJSON: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/google/uuid"
+)
+
+// importedRow is a row handed to an importRowFunc.
+type importedRow struct {
+	line      int
+	req       CreateCompanyRequest
+	decodeErr error
+}
+
+func collectRows(rows *[]importedRow) importRowFunc {
+	return func(line int, req CreateCompanyRequest, decodeErr error) error {
+		*rows = append(*rows, importedRow{line: line, req: req, decodeErr: decodeErr})
+		return nil
+	}
+}
+
+func TestReadCSVCompanies(t *testing.T) {
+	input := "name,amountOfEmployees,registered,type,description,parentId\n" +
+		"Acme,12,true,NonProfit,Makes anvils,\n" +
+		"Globex, 7 ,TRUE,Cooperative,,e3f7c0d3-ccb9-4ce4-926f-ffdbd7fdb687\n" +
+		"Initech,many,true,Corporations,,\n" +
+		"Umbrella,3\n" +
+		"Hooli,5,yes,Corporations,,\n" +
+		"Vandelay,5,true,Corporations,,abc\n"
+
+	var rows []importedRow
+	if err := readCSVCompanies(strings.NewReader(input), collectRows(&rows)); err != nil {
+		t.Fatalf("readCSVCompanies() unexpected error = %v", err)
+	}
+
+	if len(rows) != 6 {
+		t.Fatalf("readCSVCompanies() got %d rows, want 6", len(rows))
+	}
+
+	acme := rows[0]
+	if acme.decodeErr != nil || acme.line != 2 || acme.req.Name != "Acme" || acme.req.AmountOfEmployees != 12 ||
+		acme.req.Registered == nil || !*acme.req.Registered || acme.req.Type != "NonProfit" || acme.req.Description == nil || *acme.req.Description != "Makes anvils" {
+		t.Errorf("readCSVCompanies() row 1 = %+v", acme)
+	}
+
+	globex := rows[1]
+	if globex.decodeErr != nil || globex.req.AmountOfEmployees != 7 || globex.req.Description != nil ||
+		globex.req.ParentID == nil || globex.req.ParentID.String() != "e3f7c0d3-ccb9-4ce4-926f-ffdbd7fdb687" {
+		t.Errorf("readCSVCompanies() row 2 = %+v, want 7 employees, no description and a parent", globex)
+	}
+
+	for i, line := range []int{4, 5, 6, 7} {
+		row := rows[i+2]
+		if row.decodeErr == nil || row.line != line {
+			t.Errorf("readCSVCompanies() row on line %d = %+v, want a decode error", line, row)
+		}
+	}
+}
+
+func TestReadCSVCompaniesWithBOM(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Plain header", "\ufeffname,amountOfEmployees,registered,type\nAcme,12,true,NonProfit\n"},
+		{"Quoted header", "\ufeff\"name\",\"amountOfEmployees\",\"registered\",\"type\"\nAcme,12,true,NonProfit\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows []importedRow
+			if err := readCSVCompanies(strings.NewReader(tt.input), collectRows(&rows)); err != nil {
+				t.Fatalf("readCSVCompanies() unexpected error = %v", err)
+			}
+
+			if len(rows) != 1 || rows[0].decodeErr != nil || rows[0].req.Name != "Acme" {
+				t.Errorf("readCSVCompanies() got rows = %+v, want Acme", rows)
+			}
+		})
+	}
+}
+
+func TestReadCSVCompaniesHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Empty", ""},
+		{"Unknown column", "name,amountOfEmployees,registered,type,country\n"},
+		{"Repeated column", "name,name,amountOfEmployees,registered,type\n"},
+		{"Missing column", "name,amountOfEmployees,registered\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows []importedRow
+			if err := readCSVCompanies(strings.NewReader(tt.input), collectRows(&rows)); err == nil {
+				t.Errorf("readCSVCompanies() error = nil, expected an error")
+			}
+		})
+	}
+}
+
+func TestReadNDJSONCompanies(t *testing.T) {
+	input := `{"name":"Acme","amountOfEmployees":12,"registered":true,"type":"NonProfit"}` + "\n" +
+		"\n" +
+		`{"name":"Globex",` + "\n" +
+		`{"name":"Initech","amountOfEmployees":3,"registered":true,"type":"Corporations"}`
+
+	var rows []importedRow
+	if err := readNDJSONCompanies(strings.NewReader(input), collectRows(&rows)); err != nil {
+		t.Fatalf("readNDJSONCompanies() unexpected error = %v", err)
+	}
+
+	if len(rows) != 3 {
+		t.Fatalf("readNDJSONCompanies() got %d rows, want 3", len(rows))
+	}
+
+	if rows[0].decodeErr != nil || rows[0].line != 1 || rows[0].req.Name != "Acme" {
+		t.Errorf("readNDJSONCompanies() row 1 = %+v", rows[0])
+	}
+
+	if rows[1].decodeErr == nil || rows[1].line != 3 {
+		t.Errorf("readNDJSONCompanies() row 2 = %+v, want a decode error on line 3", rows[1])
+	}
+
+	if rows[2].decodeErr != nil || rows[2].line != 4 || rows[2].req.Name != "Initech" {
+		t.Errorf("readNDJSONCompanies() row 3 = %+v", rows[2])
+	}
+}
+
+func TestReadCompaniesStopsOnRowError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	fn := func(int, CreateCompanyRequest, error) error {
+		calls++
+		return stop
+	}
+
+	csvInput := "name,amountOfEmployees,registered,type\nAcme,1,true,NonProfit\nGlobex,1,true,NonProfit\n"
+	if err := readCSVCompanies(strings.NewReader(csvInput), fn); !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("readCSVCompanies() error = %v after %d rows, want %v after 1", err, calls, stop)
+	}
+
+	calls = 0
+	ndjsonInput := "{}\n{}\n"
+	if err := readNDJSONCompanies(strings.NewReader(ndjsonInput), fn); !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("readNDJSONCompanies() error = %v after %d rows, want %v after 1", err, calls, stop)
+	}
+}
+
+func TestImportUnregisteredCompanies(t *testing.T) {
+	tests := []struct {
+		name  string
+		read  func(io.Reader, importRowFunc) error
+		input string
+	}{
+		{"CSV", readCSVCompanies, "name,amountOfEmployees,registered,type\nAcme,5,false,NonProfit\nGlobex,5,,NonProfit\n"},
+		{"NDJSON", readNDJSONCompanies, `{"name":"Acme","amountOfEmployees":5,"registered":false,"type":"NonProfit"}` + "\n" +
+			`{"name":"Globex","amountOfEmployees":5,"type":"NonProfit"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeCompanyRepository{}
+			h := NewCompanyHandler(repo, nil, fakeCompanyTypeRepository{}, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			user := &domain.User{ID: 1, UUID: uuid.New(), Role: domain.UserRoleEditor}
+
+			var statuses []string
+			err := tt.read(strings.NewReader(tt.input), func(_ int, req CreateCompanyRequest, decodeErr error) error {
+				row, appErr := h.importCompany(context.Background(), user, req, decodeErr)
+				if appErr != nil {
+					return appErr
+				}
+				statuses = append(statuses, row.Status)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("import unexpected error = %v", err)
+			}
+
+			if len(statuses) != 2 || statuses[0] != importRowCreated || statuses[1] != importRowInvalid {
+				t.Fatalf("import got row statuses %v, want [%s %s]", statuses, importRowCreated, importRowInvalid)
+			}
+
+			if len(repo.created) != 1 || repo.created[0].Registered || repo.created[0].Status != domain.CompanyStatusDraft {
+				t.Errorf("import created %+v, want a single unregistered draft company", repo.created)
+			}
+		})
+	}
+}
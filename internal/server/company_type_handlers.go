@@ -0,0 +1,166 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/ashtishad/xm/common"
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type CompanyTypeHandler struct {
+	typeRepo domain.CompanyTypeRepository
+	l        *slog.Logger
+}
+
+func NewCompanyTypeHandler(typeRepo domain.CompanyTypeRepository, logger *slog.Logger) *CompanyTypeHandler {
+	return &CompanyTypeHandler{
+		typeRepo: typeRepo,
+		l:        logger,
+	}
+}
+
+// ListCompanyTypes godoc
+// @Summary List company types
+// @Description Returns the types companies may be created with, ordered by name.
+// @Tags company-types
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} ListCompanyTypesResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /company-types [get]
+func (h *CompanyTypeHandler) ListCompanyTypes(c *gin.Context) {
+	companyTypes, appErr := h.typeRepo.List(c.Request.Context())
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ListCompanyTypesResponse{CompanyTypes: companyTypes})
+}
+
+// GetCompanyType godoc
+// @Summary Get a company type
+// @Description Returns a company type by its exact name.
+// @Tags company-types
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param name path string true "Company type name"
+// @Success 200 {object} domain.CompanyType
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /company-types/{name} [get]
+func (h *CompanyTypeHandler) GetCompanyType(c *gin.Context) {
+	companyType, appErr := h.typeRepo.FindByName(c.Request.Context(), c.Param("name"))
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, companyType)
+}
+
+// CreateCompanyType godoc
+// @Summary Create a company type
+// @Description Adds a type companies may be created with. Admin only.
+// @Description Other server instances accept the new type within COMPANY_TYPE_CACHE_TTL.
+// @Tags company-types
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body CreateCompanyTypeRequest true "Company type"
+// @Success 201 {object} domain.CompanyType
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /company-types [post]
+func (h *CompanyTypeHandler) CreateCompanyType(c *gin.Context) {
+	var req CreateCompanyTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "name must not be blank"})
+		return
+	}
+
+	companyType, appErr := h.typeRepo.Create(c.Request.Context(), &domain.CompanyType{Name: name, Description: req.Description})
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, companyType)
+}
+
+// UpdateCompanyType godoc
+// @Summary Update a company type
+// @Description Replaces the description of a company type. Names can't be changed. Admin only.
+// @Tags company-types
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param name path string true "Company type name"
+// @Param input body UpdateCompanyTypeRequest true "Company type description"
+// @Success 200 {object} domain.CompanyType
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /company-types/{name} [put]
+func (h *CompanyTypeHandler) UpdateCompanyType(c *gin.Context) {
+	var req UpdateCompanyTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	companyType, appErr := h.typeRepo.Update(c.Request.Context(), c.Param("name"), req.Description)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, companyType)
+}
+
+// DeleteCompanyType godoc
+// @Summary Delete a company type
+// @Description Removes a company type. Admin only.
+// @Description Rejected with 409 while any company, including soft-deleted ones, is of that type.
+// @Tags company-types
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param name path string true "Company type name"
+// @Success 204 "No Content"
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /company-types/{name} [delete]
+func (h *CompanyTypeHandler) DeleteCompanyType(c *gin.Context) {
+	if appErr := h.typeRepo.Delete(c.Request.Context(), c.Param("name")); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,6 +1,12 @@
 package server
 
-import "github.com/ashtishad/xm/internal/domain"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/google/uuid"
+)
 
 // ErrorResponse represents a standardized error message structure.
 // @Description ErrorResponse provides a consistent error format.
@@ -40,20 +46,346 @@ type LoginResponse struct {
 	User domain.User `json:"user"`
 }
 
-// CreateCompanyRequest holds the data for creating a new company
+// RefreshTokenRequest carries the refresh token to exchange.
+// @Description RefreshToken is optional when the refreshToken cookie is sent.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// RefreshTokenResponse contains the user data returned after a successful token refresh.
+// @Description RefreshTokenResponse includes the authenticated user's details.
+type RefreshTokenResponse struct {
+	User domain.User `json:"user"`
+}
+
+// UpdateUserRoleRequest holds the new role of a user.
+// @Description Role must be one of admin, editor or viewer.
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin editor viewer"`
+}
+
+// CreateCompanyRequest holds the data for creating a new company.
+// Registered is a pointer so that an explicit false passes the required check.
+// @Description Type must name one of the company types listed by List Company Types.
+// @Description Registered companies start out registered, others as draft.
 type CreateCompanyRequest struct {
-	Name              string  `json:"name" binding:"required,max=15"`
-	Description       *string `json:"description" binding:"omitempty,max=3000"`
-	AmountOfEmployees int     `json:"amountOfEmployees" binding:"required,min=1"`
-	Registered        bool    `json:"registered" binding:"required"`
-	Type              string  `json:"type" binding:"required,oneof=Corporations NonProfit Cooperative 'Sole Proprietorship'"`
+	Name              string     `json:"name" binding:"required,max=15"`
+	Description       *string    `json:"description" binding:"omitempty,max=3000"`
+	AmountOfEmployees int        `json:"amountOfEmployees" binding:"required,min=1"`
+	Registered        *bool      `json:"registered" binding:"required"`
+	Type              string     `json:"type" binding:"required,max=50"`
+	ParentID          *uuid.UUID `json:"parentId" swaggertype:"string" format:"uuid"`
 }
 
-// UpdateCompanyRequest holds the data for updating a company
+// UpdateCompanyRequest holds the data for updating a company.
+// Status and Registered follow the lifecycle of the company: they are only read to reject
+// requests setting them, as they change through status transitions.
+// @Description Type must name one of the company types listed by List Company Types.
+// @Description The status and registered fields are rejected; change them with Transition Company Status.
 type UpdateCompanyRequest struct {
 	Name              *string `json:"name" binding:"omitempty,max=15"`
 	Description       *string `json:"description" binding:"omitempty,max=3000"`
 	AmountOfEmployees *int    `json:"amountOfEmployees" binding:"omitempty,min=1"`
-	Registered        *bool   `json:"registered" binding:"omitempty"`
-	Type              *string `json:"type" binding:"omitempty,oneof=Corporations NonProfit Cooperative 'Sole Proprietorship'"`
+	Type              *string `json:"type" binding:"omitempty,max=50"`
+	Registered        *bool   `json:"registered" swaggerignore:"true"`
+	Status            *string `json:"status" swaggerignore:"true"`
+}
+
+// TransitionCompanyRequest holds the lifecycle status a company moves to and why.
+// @Description Allowed transitions: draft to pending_registration; pending_registration to registered or back to draft;
+// @Description registered to suspended or dissolved; suspended to registered or dissolved. Dissolved is final.
+type TransitionCompanyRequest struct {
+	Status string `json:"status" binding:"required,oneof=draft pending_registration registered suspended dissolved"`
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// ListCompaniesRequest holds the query parameters for listing companies.
+// Cursor is the opaque nextCursor value returned by the previous page.
+// Timestamps are RFC3339; createdAfter is inclusive and createdBefore exclusive.
+// Mine restricts the listing to the caller's companies and takes precedence over OwnerID.
+type ListCompaniesRequest struct {
+	Cursor        string     `form:"cursor"`
+	Limit         int        `form:"limit" binding:"omitempty,min=1,max=100"`
+	Type          *string    `form:"type" binding:"omitempty,max=50"`
+	Registered    *bool      `form:"registered"`
+	Status        *string    `form:"status" binding:"omitempty,oneof=draft pending_registration registered suspended dissolved"`
+	MinEmployees  *int       `form:"minEmployees" binding:"omitempty,min=1"`
+	MaxEmployees  *int       `form:"maxEmployees" binding:"omitempty,min=1"`
+	CreatedAfter  *time.Time `form:"createdAfter" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"createdBefore" time_format:"2006-01-02T15:04:05Z07:00"`
+	OwnerID       string     `form:"ownerId" binding:"omitempty,uuid"`
+	Mine          bool       `form:"mine"`
+}
+
+// ExportCompaniesRequest holds the query parameters of a company export.
+// It takes the filters of ListCompaniesRequest, except for cursor and limit.
+// IncludeDeleted also exports soft-deleted companies and is reserved to admins.
+type ExportCompaniesRequest struct {
+	ListCompaniesRequest
+	Format         string `form:"format" binding:"required,oneof=csv ndjson"`
+	IncludeDeleted bool   `form:"includeDeleted"`
+}
+
+// SearchCompaniesRequest holds the query parameters of a company search.
+// Q is matched against names by similarity and against descriptions as a web search style query.
+type SearchCompaniesRequest struct {
+	Q     string `form:"q" binding:"required,min=2,max=100"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+// SearchCompaniesResponse contains the companies matching a search, best matches first.
+type SearchCompaniesResponse struct {
+	Results []CompanySearchHit `json:"results"`
+}
+
+// CompanySearchHit is a company matching a search.
+// @Description Highlights are HTML-escaped, with the matched text wrapped in <mark> elements.
+// @Description The description highlight is omitted when only the name matched.
+type CompanySearchHit struct {
+	Company    domain.Company         `json:"company"`
+	Score      float64                `json:"score"`
+	Highlights CompanySearchHighlight `json:"highlights"`
+}
+
+// CompanySearchHighlight holds the highlighted name and description snippet of a search hit.
+type CompanySearchHighlight struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// NameAvailabilityRequest holds the company name to check.
+// Name follows the length rule of CreateCompanyRequest.
+type NameAvailabilityRequest struct {
+	Name string `form:"name" binding:"required,max=15"`
+}
+
+// NameAvailabilityResponse reports whether a company name is free.
+// @Description Suggestions lists available variants of a taken name and is omitted when the name is available.
+type NameAvailabilityResponse struct {
+	Name        string   `json:"name"`
+	Available   bool     `json:"available"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// ListCompaniesResponse contains a page of companies.
+// @Description NextCursor is omitted on the last page.
+type ListCompaniesResponse struct {
+	Companies  []domain.Company `json:"companies"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+// GetCompanyRequest holds the query parameters for getting a company.
+// AsOf is an RFC3339 timestamp; when set, the company is returned as it was at that time.
+type GetCompanyRequest struct {
+	AsOf *time.Time `form:"asOf" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// DeleteCompanyRequest holds the query parameters for deleting a company.
+// Cascade also deletes the active subsidiaries of the company, recursively.
+type DeleteCompanyRequest struct {
+	Cascade bool `form:"cascade"`
+}
+
+// SetParentRequest holds the new parent of a company.
+// @Description ParentID is the ID of the parent company, or null to make the company a top-level company.
+type SetParentRequest struct {
+	ParentID *uuid.UUID `json:"parentId" swaggertype:"string" format:"uuid"`
+}
+
+// ListSubsidiariesRequest holds the query parameters for listing the subsidiaries of a company.
+// Recursive includes the subsidiaries of subsidiaries, at any depth.
+type ListSubsidiariesRequest struct {
+	Recursive bool `form:"recursive"`
+}
+
+// RelatedCompaniesResponse contains companies of the hierarchy of a company.
+type RelatedCompaniesResponse struct {
+	Companies []domain.RelatedCompany `json:"companies"`
+}
+
+// CreateCompanyTypeRequest holds a new company type.
+// @Description Name must be unique ignoring case and can't be changed later.
+type CreateCompanyTypeRequest struct {
+	Name        string  `json:"name" binding:"required,max=50"`
+	Description *string `json:"description" binding:"omitempty,max=500"`
+}
+
+// UpdateCompanyTypeRequest holds the new description of a company type; null clears it.
+type UpdateCompanyTypeRequest struct {
+	Description *string `json:"description" binding:"omitempty,max=500"`
+}
+
+// ListCompanyTypesResponse contains all company types, ordered by name.
+type ListCompanyTypesResponse struct {
+	CompanyTypes []domain.CompanyType `json:"companyTypes"`
+}
+
+// CreateAddressRequest holds the data for adding an address to a company.
+// @Description Kind is registered or operating; a company has at most one registered address.
+// @Description Country is an upper case ISO 3166-1 alpha-2 code, e.g. DE.
+type CreateAddressRequest struct {
+	Kind       string  `json:"kind" binding:"required,oneof=registered operating"`
+	Line1      string  `json:"line1" binding:"required,max=200"`
+	Line2      *string `json:"line2" binding:"omitempty,max=200"`
+	City       string  `json:"city" binding:"required,max=100"`
+	PostalCode *string `json:"postalCode" binding:"omitempty,max=20"`
+	Region     *string `json:"region" binding:"omitempty,max=100"`
+	Country    string  `json:"country" binding:"required,iso3166_1_alpha2"`
+}
+
+// UpdateAddressRequest holds the data for updating an address of a company.
+type UpdateAddressRequest struct {
+	Kind       *string `json:"kind" binding:"omitempty,oneof=registered operating"`
+	Line1      *string `json:"line1" binding:"omitempty,min=1,max=200"`
+	Line2      *string `json:"line2" binding:"omitempty,max=200"`
+	City       *string `json:"city" binding:"omitempty,min=1,max=100"`
+	PostalCode *string `json:"postalCode" binding:"omitempty,max=20"`
+	Region     *string `json:"region" binding:"omitempty,max=100"`
+	Country    *string `json:"country" binding:"omitempty,iso3166_1_alpha2"`
+}
+
+// ListAddressesResponse contains the addresses of a company, oldest first.
+type ListAddressesResponse struct {
+	Addresses []domain.CompanyAddress `json:"addresses"`
+}
+
+// CreateContactRequest holds the data for adding a contact person to a company.
+type CreateContactRequest struct {
+	Name  string  `json:"name" binding:"required,max=100"`
+	Role  *string `json:"role" binding:"omitempty,max=100"`
+	Email *string `json:"email" binding:"omitempty,email,max=255"`
+	Phone *string `json:"phone" binding:"omitempty,max=50"`
+}
+
+// UpdateContactRequest holds the data for updating a contact person of a company.
+type UpdateContactRequest struct {
+	Name  *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Role  *string `json:"role" binding:"omitempty,max=100"`
+	Email *string `json:"email" binding:"omitempty,email,max=255"`
+	Phone *string `json:"phone" binding:"omitempty,max=50"`
+}
+
+// ListContactsResponse contains the contact people of a company, oldest first.
+type ListContactsResponse struct {
+	Contacts []domain.CompanyContact `json:"contacts"`
+}
+
+// CreateIdentifierRequest holds a registration identifier of a company.
+// @Description Scheme is vat or lei. Spaces, dots and dashes are stripped from the value and letters upper cased.
+// @Description An LEI must have valid ISO 17442 check digits; a VAT number starts with its country prefix.
+type CreateIdentifierRequest struct {
+	Scheme string `json:"scheme" binding:"required,oneof=vat lei"`
+	Value  string `json:"value" binding:"required,max=50"`
+}
+
+// ListIdentifiersResponse contains the registration identifiers of a company, ordered by scheme.
+type ListIdentifiersResponse struct {
+	Identifiers []domain.CompanyIdentifier `json:"identifiers"`
+}
+
+// TransferOwnershipRequest holds the user a company is handed over to.
+// @Description NewOwnerID must be the ID of an existing user.
+type TransferOwnershipRequest struct {
+	NewOwnerID string `json:"newOwnerId" binding:"required,uuid"`
+}
+
+// ListCompanyEventsRequest holds the query parameters for listing the events of a company.
+// Cursor is the opaque nextCursor value returned by the previous page.
+type ListCompanyEventsRequest struct {
+	Cursor    string  `form:"cursor"`
+	Limit     int     `form:"limit" binding:"omitempty,min=1,max=100"`
+	EventType *string `form:"eventType" binding:"omitempty,max=50"`
+}
+
+// ListCompanyEventsResponse contains a page of company events.
+// @Description NextCursor is omitted on the last page.
+type ListCompanyEventsResponse struct {
+	Events     []domain.Event `json:"events"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+}
+
+// ListCompanyVersionsRequest holds the query parameters for listing the versions of a company.
+// Cursor is the opaque nextCursor value returned by the previous page.
+type ListCompanyVersionsRequest struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// ListCompanyVersionsResponse contains a page of company versions.
+// @Description NextCursor is omitted on the last page.
+type ListCompanyVersionsResponse struct {
+	Versions   []domain.CompanyVersion `json:"versions"`
+	NextCursor string                  `json:"nextCursor,omitempty"`
+}
+
+// DiffCompanyVersionsRequest holds the two versions of a company to compare.
+// From may be later than To, in which case the changes undo the history in between.
+type DiffCompanyVersionsRequest struct {
+	From int `form:"from" binding:"required,min=1"`
+	To   int `form:"to" binding:"required,min=1"`
+}
+
+// CompanyVersionDiffResponse holds the fields that differ between two versions of a company.
+// @Description Changes are keyed by field name and hold the value in version from and in version to.
+type CompanyVersionDiffResponse struct {
+	From    int                           `json:"from"`
+	To      int                           `json:"to"`
+	Changes map[string]domain.FieldChange `json:"changes"`
+}
+
+// BatchCompaniesRequest holds the operations of a company batch, applied in order.
+// @Description Mode is atomic (the default, all or nothing) or partial (each operation succeeds or fails on its own).
+// @Description At most 100 operations are accepted per batch.
+type BatchCompaniesRequest struct {
+	Mode       string                  `json:"mode" binding:"omitempty,oneof=atomic partial"`
+	Operations []BatchCompanyOperation `json:"operations" binding:"required,min=1,max=100"`
+}
+
+// BatchCompanyOperation is a single create, update or delete within a batch.
+// @Description Data holds a CreateCompanyRequest for create and an UpdateCompanyRequest for update.
+// @Description ID is required for update and delete; Version optionally guards them like an If-Match header.
+// @Description Cascade deletes the subsidiaries of a deleted company, like the cascade parameter of Delete Company.
+type BatchCompanyOperation struct {
+	Op      string          `json:"op" binding:"required,oneof=create update delete"`
+	ID      string          `json:"id" binding:"omitempty,uuid"`
+	Version *int            `json:"version" binding:"omitempty,min=1"`
+	Cascade bool            `json:"cascade"`
+	Data    json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+// BatchCompanyResult is the outcome of a single batch operation.
+// @Description Status is the HTTP status the operation would have had on its own endpoint,
+// @Description or 424 for operations not applied because another operation aborted an atomic batch.
+type BatchCompanyResult struct {
+	Index   int             `json:"index"`
+	Op      string          `json:"op"`
+	Status  int             `json:"status"`
+	Company *domain.Company `json:"company,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// BatchCompaniesResponse contains the result of every batch operation, in request order.
+type BatchCompaniesResponse struct {
+	Results []BatchCompanyResult `json:"results"`
+}
+
+// ImportCompaniesResponse reports the outcome of every row of a company import.
+// @Description Error is set when the import stopped early; rows before it have been processed.
+type ImportCompaniesResponse struct {
+	Created    int                `json:"created"`
+	Duplicates int                `json:"duplicates"`
+	Invalid    int                `json:"invalid"`
+	Rows       []ImportCompanyRow `json:"rows"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// ImportCompanyRow is the outcome of a single import row.
+// @Description Status is created, duplicate (a company with this name already exists) or invalid.
+// @Description Line is the line of the row in the imported document.
+type ImportCompanyRow struct {
+	Line      int        `json:"line"`
+	Status    string     `json:"status"`
+	Name      string     `json:"name,omitempty"`
+	CompanyID *uuid.UUID `json:"companyId,omitempty"`
+	Error     string     `json:"error,omitempty"`
 }
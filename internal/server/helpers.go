@@ -1,11 +1,24 @@
 package server
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/ashtishad/xm/common"
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/ashtishad/xm/internal/security"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 // formatValidationError formats validation errors into a single string
@@ -53,3 +66,479 @@ func formatValidationError(err error) string {
 
 	return strings.Join(messages, ". ")
 }
+
+// encodeCursor wraps a keyset position into an opaque, URL-safe pagination cursor.
+func encodeCursor(position string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(position))
+}
+
+// decodeCursor reverses encodeCursor.
+func decodeCursor(cursor string) (string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	return string(decoded), nil
+}
+
+// buildCompanyFilter converts validated listing query parameters into a domain.CompanyFilter,
+// applying the default page limit and decoding the cursor.
+func buildCompanyFilter(req ListCompaniesRequest) (domain.CompanyFilter, error) {
+	filter := domain.CompanyFilter{
+		Type:          req.Type,
+		Registered:    req.Registered,
+		Status:        req.Status,
+		MinEmployees:  req.MinEmployees,
+		MaxEmployees:  req.MaxEmployees,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		Limit:         req.Limit,
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = common.DefaultPageLimit
+	}
+
+	if req.MinEmployees != nil && req.MaxEmployees != nil && *req.MinEmployees > *req.MaxEmployees {
+		return filter, errors.New("minEmployees must not exceed maxEmployees")
+	}
+
+	if req.CreatedAfter != nil && req.CreatedBefore != nil && !req.CreatedAfter.Before(*req.CreatedBefore) {
+		return filter, errors.New("createdAfter must be before createdBefore")
+	}
+
+	if req.OwnerID != "" {
+		ownerID, err := uuid.Parse(req.OwnerID)
+		if err != nil {
+			return filter, errors.New("invalid ownerId")
+		}
+
+		filter.OwnerID = &ownerID
+	}
+
+	if req.Cursor != "" {
+		position, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+
+		afterID, err := uuid.Parse(position)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+
+		filter.AfterID = &afterID
+	}
+
+	return filter, nil
+}
+
+// eventCursorSeparator joins the created_at and id parts of an event cursor position.
+const eventCursorSeparator = "|"
+
+// buildEventFilter converts validated event listing query parameters into a domain.EventFilter
+// for the given company, applying the default page limit and decoding the cursor.
+func buildEventFilter(companyID uuid.UUID, req ListCompanyEventsRequest) (domain.EventFilter, error) {
+	filter := domain.EventFilter{
+		AggregateID: companyID,
+		EventType:   req.EventType,
+		Limit:       req.Limit,
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = common.DefaultPageLimit
+	}
+
+	if req.Cursor != "" {
+		position, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+
+		createdAtPart, idPart, found := strings.Cut(position, eventCursorSeparator)
+		if !found {
+			return filter, errors.New("invalid cursor")
+		}
+
+		createdAt, err := time.Parse(time.RFC3339Nano, createdAtPart)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+
+		afterID, err := uuid.Parse(idPart)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+
+		filter.AfterCreatedAt = &createdAt
+		filter.AfterID = &afterID
+	}
+
+	return filter, nil
+}
+
+// buildCompanyVersionFilter converts validated version listing query parameters into a
+// domain.CompanyVersionFilter, applying the default page limit and decoding the cursor,
+// which holds the last version of the previous page.
+func buildCompanyVersionFilter(companyID uuid.UUID, req ListCompanyVersionsRequest) (domain.CompanyVersionFilter, error) {
+	filter := domain.CompanyVersionFilter{
+		CompanyID: companyID,
+		Limit:     req.Limit,
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = common.DefaultPageLimit
+	}
+
+	if req.Cursor != "" {
+		position, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+
+		afterVersion, err := strconv.Atoi(position)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+
+		filter.AfterVersion = &afterVersion
+	}
+
+	return filter, nil
+}
+
+// companyETag returns the entity tag of a version of a company, a strong ETag holding the version.
+func companyETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// companyCacheControl makes clients and proxies revalidate a company on every use. The response
+// depends on the caller's authorization, so shared caches must not store it.
+const companyCacheControl = "private, no-cache"
+
+// setCompanyCacheHeaders sets the validators and caching policy of a response holding a single company.
+func setCompanyCacheHeaders(c *gin.Context, company *domain.Company) {
+	c.Header("ETag", companyETag(company.Version))
+	if company.UpdatedAt != nil {
+		c.Header("Last-Modified", company.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	c.Header("Cache-Control", companyCacheControl)
+}
+
+// isNotModified evaluates the If-None-Match and If-Modified-Since request headers against the current
+// ETag and modification time of a resource, following RFC 9110: If-None-Match takes precedence and
+// uses weak comparison, If-Modified-Since is only considered without it and has second precision.
+func isNotModified(ifNoneMatch, ifModifiedSince, etag string, lastModified time.Time) bool {
+	if ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// parseIfMatch returns the company version required by an If-Match header value.
+// A missing header or "*" imposes no version and yields nil. A value that isn't a single ETag
+// produced by companyETag, including weak ETags, can never match and yields an error.
+func parseIfMatch(header string) (*int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return nil, errors.New("If-Match must be a single strong ETag")
+	}
+
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version < 1 {
+		return nil, errors.New("If-Match does not match any version of the company")
+	}
+
+	return &version, nil
+}
+
+// authorizedUser returns the user stored in the context by AuthMiddleware.
+func authorizedUser(c *gin.Context) (*domain.User, bool) {
+	value, exists := c.Get("authorizedUser")
+	if !exists {
+		return nil, false
+	}
+
+	user, ok := value.(*domain.User)
+	return user, ok
+}
+
+// tokenClaims returns the access token claims stored in the context by AuthMiddleware.
+func tokenClaims(c *gin.Context) (*security.JWTClaims, bool) {
+	value, exists := c.Get("tokenClaims")
+	if !exists {
+		return nil, false
+	}
+
+	claims, ok := value.(*security.JWTClaims)
+	return claims, ok
+}
+
+// liftDeadlines removes the server's read and write timeouts from a request that legitimately
+// streams for longer than ordinary requests, such as imports and exports.
+func liftDeadlines(c *gin.Context) error {
+	controller := http.NewResponseController(c.Writer)
+	if err := controller.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	return controller.SetWriteDeadline(time.Time{})
+}
+
+// newCompany builds the company described by a validated CreateCompanyRequest, owned by owner.
+func newCompany(req CreateCompanyRequest, owner uuid.UUID) *domain.Company {
+	return &domain.Company{
+		ID:                uuid.New(),
+		Name:              req.Name,
+		Description:       req.Description,
+		AmountOfEmployees: req.AmountOfEmployees,
+		Registered:        *req.Registered,
+		Status:            domain.InitialCompanyStatus(*req.Registered),
+		Type:              req.Type,
+		OwnerID:           &owner,
+		ParentID:          req.ParentID,
+	}
+}
+
+// errLifecycleUpdate is returned for updates setting the status or registered fields of a company.
+const errLifecycleUpdate = "status and registered can't be updated directly, transition the company status instead"
+
+// checkLifecycleUpdate rejects updates setting the status or registered fields, which only
+// change through status transitions.
+func checkLifecycleUpdate(req UpdateCompanyRequest) common.AppError {
+	if req.Status != nil || req.Registered != nil {
+		return common.NewBadRequestError(errLifecycleUpdate)
+	}
+
+	return nil
+}
+
+// companyUpdates converts an UpdateCompanyRequest into the column updates applied by CompanyRepository.Update.
+func companyUpdates(req UpdateCompanyRequest) map[string]any {
+	updates := make(map[string]any)
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+
+	if req.AmountOfEmployees != nil {
+		updates["amount_of_employees"] = *req.AmountOfEmployees
+	}
+
+	if req.Type != nil {
+		updates["type"] = *req.Type
+	}
+
+	return updates
+}
+
+// addressUpdates converts an UpdateAddressRequest into the column updates applied by
+// CompanyDetailRepository.UpdateAddress.
+func addressUpdates(req UpdateAddressRequest) map[string]any {
+	updates := make(map[string]any)
+	if req.Kind != nil {
+		updates["kind"] = *req.Kind
+	}
+
+	if req.Line1 != nil {
+		updates["line1"] = *req.Line1
+	}
+
+	if req.Line2 != nil {
+		updates["line2"] = *req.Line2
+	}
+
+	if req.City != nil {
+		updates["city"] = *req.City
+	}
+
+	if req.PostalCode != nil {
+		updates["postal_code"] = *req.PostalCode
+	}
+
+	if req.Region != nil {
+		updates["region"] = *req.Region
+	}
+
+	if req.Country != nil {
+		updates["country"] = *req.Country
+	}
+
+	return updates
+}
+
+// contactUpdates converts an UpdateContactRequest into the column updates applied by
+// CompanyDetailRepository.UpdateContact.
+func contactUpdates(req UpdateContactRequest) map[string]any {
+	updates := make(map[string]any)
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+
+	if req.Role != nil {
+		updates["role"] = *req.Role
+	}
+
+	if req.Email != nil {
+		updates["email"] = *req.Email
+	}
+
+	if req.Phone != nil {
+		updates["phone"] = *req.Phone
+	}
+
+	return updates
+}
+
+const (
+	batchModeAtomic  = "atomic"
+	batchModePartial = "partial"
+)
+
+// errBatchAborted is reported for the operations of an atomic batch that were not applied
+// because another operation failed.
+const errBatchAborted = "not applied, another operation of the batch failed"
+
+// parseBatchOperation validates a batch operation with the rules of the matching single company
+// endpoint and converts it into a domain operation. Created companies are owned by owner.
+func parseBatchOperation(op BatchCompanyOperation, owner uuid.UUID) (domain.CompanyOperation, common.AppError) {
+	if err := binding.Validator.ValidateStruct(op); err != nil {
+		return domain.CompanyOperation{}, common.NewBadRequestError(formatValidationError(err))
+	}
+
+	parsed := domain.CompanyOperation{Type: domain.CompanyOperationType(op.Op), ExpectedVersion: op.Version, Cascade: op.Cascade}
+
+	if parsed.Type == domain.CompanyOperationCreate {
+		var req CreateCompanyRequest
+		if appErr := decodeBatchData(op.Data, &req); appErr != nil {
+			return domain.CompanyOperation{}, appErr
+		}
+
+		parsed.Company = newCompany(req, owner)
+
+		return parsed, nil
+	}
+
+	id, err := uuid.Parse(op.ID)
+	if err != nil {
+		return domain.CompanyOperation{}, common.NewBadRequestError("Invalid company ID")
+	}
+	parsed.ID = id
+
+	if parsed.Type == domain.CompanyOperationUpdate {
+		var req UpdateCompanyRequest
+		if appErr := decodeBatchData(op.Data, &req); appErr != nil {
+			return domain.CompanyOperation{}, appErr
+		}
+
+		if appErr := checkLifecycleUpdate(req); appErr != nil {
+			return domain.CompanyOperation{}, appErr
+		}
+
+		parsed.Updates = companyUpdates(req)
+		if len(parsed.Updates) == 0 {
+			return domain.CompanyOperation{}, common.NewBadRequestError(errNoUpdates)
+		}
+	}
+
+	return parsed, nil
+}
+
+// decodeBatchData decodes the data of a batch operation into req and validates it.
+func decodeBatchData(data json.RawMessage, req any) common.AppError {
+	if len(data) == 0 {
+		return common.NewBadRequestError("data is required")
+	}
+
+	if err := json.Unmarshal(data, req); err != nil {
+		return common.NewBadRequestError("invalid data: " + err.Error())
+	}
+
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return common.NewBadRequestError(formatValidationError(err))
+	}
+
+	return nil
+}
+
+// abortBatch returns the status of the first failed operation of an atomic batch, after marking
+// every other operation as not applied. It returns http.StatusOK when no operation failed.
+func abortBatch(results []BatchCompanyResult) int {
+	status := http.StatusOK
+	for _, result := range results {
+		if result.Status >= http.StatusBadRequest {
+			status = result.Status
+			break
+		}
+	}
+
+	if status == http.StatusOK {
+		return status
+	}
+
+	for i, result := range results {
+		if result.Status < http.StatusBadRequest {
+			results[i] = BatchCompanyResult{Index: result.Index, Op: result.Op, Status: http.StatusFailedDependency, Error: errBatchAborted}
+		}
+	}
+
+	return status
+}
+
+// Markup of the matches within search highlights.
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+)
+
+// highlightSnippet HTML-escapes a description snippet of domain.CompanySearchResult and turns its
+// match delimiters into highlight markup.
+func highlightSnippet(snippet string) string {
+	return strings.NewReplacer(domain.SearchMatchStart, highlightStart, domain.SearchMatchStop, highlightStop).
+		Replace(html.EscapeString(snippet))
+}
+
+// highlightName HTML-escapes a company name and marks the occurrences of query, ignoring case.
+// Names that only resemble the query are returned without markup.
+func highlightName(name, query string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(name); {
+		end := i + len(query)
+		if end <= len(name) && strings.EqualFold(name[i:end], query) {
+			b.WriteString(highlightStart + html.EscapeString(name[i:end]) + highlightStop)
+			i = end
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(name[i:])
+		b.WriteString(html.EscapeString(name[i : i+size]))
+		i += size
+	}
+
+	return b.String()
+}
@@ -0,0 +1,366 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xm/common"
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := uuid.New().String()
+
+	decoded, err := decodeCursor(encodeCursor(id))
+	if err != nil {
+		t.Fatalf("decodeCursor() unexpected error = %v", err)
+	}
+
+	if decoded != id {
+		t.Errorf("decodeCursor() got = %v, want %v", decoded, id)
+	}
+
+	if _, err := decodeCursor("not base64!"); err == nil {
+		t.Errorf("decodeCursor() error = nil, expected an error")
+	}
+}
+
+func TestBuildCompanyFilter(t *testing.T) {
+	ten, five := 10, 5
+	id := uuid.New()
+
+	tests := []struct {
+		name        string
+		req         ListCompaniesRequest
+		expectError bool
+		expectLimit int
+		expectAfter *uuid.UUID
+	}{
+		{"Defaults", ListCompaniesRequest{}, false, common.DefaultPageLimit, nil},
+		{"Explicit limit", ListCompaniesRequest{Limit: 50}, false, 50, nil},
+		{"Valid cursor", ListCompaniesRequest{Cursor: encodeCursor(id.String())}, false, common.DefaultPageLimit, &id},
+		{"Cursor without uuid", ListCompaniesRequest{Cursor: encodeCursor("abc")}, true, 0, nil},
+		{"Malformed cursor", ListCompaniesRequest{Cursor: "%%%"}, true, 0, nil},
+		{"Inverted employee range", ListCompaniesRequest{MinEmployees: &ten, MaxEmployees: &five}, true, 0, nil},
+		{"Owner filter", ListCompaniesRequest{OwnerID: id.String()}, false, common.DefaultPageLimit, nil},
+		{"Invalid owner filter", ListCompaniesRequest{OwnerID: "abc"}, true, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := buildCompanyFilter(tt.req)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("buildCompanyFilter() error = nil, expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("buildCompanyFilter() unexpected error = %v", err)
+			}
+
+			if filter.Limit != tt.expectLimit {
+				t.Errorf("buildCompanyFilter() got Limit = %v, want %v", filter.Limit, tt.expectLimit)
+			}
+
+			if (filter.AfterID == nil) != (tt.expectAfter == nil) ||
+				(filter.AfterID != nil && *filter.AfterID != *tt.expectAfter) {
+				t.Errorf("buildCompanyFilter() got AfterID = %v, want %v", filter.AfterID, tt.expectAfter)
+			}
+		})
+	}
+}
+
+func TestBuildEventFilter(t *testing.T) {
+	companyID, eventID := uuid.New(), uuid.New()
+	createdAt := time.Date(2024, 9, 27, 16, 55, 36, 267459000, time.UTC)
+	validCursor := encodeCursor(createdAt.Format(time.RFC3339Nano) + eventCursorSeparator + eventID.String())
+
+	tests := []struct {
+		name        string
+		req         ListCompanyEventsRequest
+		expectError bool
+		expectLimit int
+		expectAfter bool
+	}{
+		{"Defaults", ListCompanyEventsRequest{}, false, common.DefaultPageLimit, false},
+		{"Explicit limit", ListCompanyEventsRequest{Limit: 5}, false, 5, false},
+		{"Valid cursor", ListCompanyEventsRequest{Cursor: validCursor}, false, common.DefaultPageLimit, true},
+		{"Cursor without separator", ListCompanyEventsRequest{Cursor: encodeCursor(eventID.String())}, true, 0, false},
+		{"Cursor with invalid time", ListCompanyEventsRequest{Cursor: encodeCursor("yesterday|" + eventID.String())}, true, 0, false},
+		{"Malformed cursor", ListCompanyEventsRequest{Cursor: "%%%"}, true, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := buildEventFilter(companyID, tt.req)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("buildEventFilter() error = nil, expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("buildEventFilter() unexpected error = %v", err)
+			}
+
+			if filter.AggregateID != companyID || filter.Limit != tt.expectLimit {
+				t.Errorf("buildEventFilter() got AggregateID = %v, Limit = %v", filter.AggregateID, filter.Limit)
+			}
+
+			if !tt.expectAfter {
+				return
+			}
+
+			if filter.AfterID == nil || *filter.AfterID != eventID ||
+				filter.AfterCreatedAt == nil || !filter.AfterCreatedAt.Equal(createdAt) {
+				t.Errorf("buildEventFilter() got AfterCreatedAt = %v, AfterID = %v", filter.AfterCreatedAt, filter.AfterID)
+			}
+		})
+	}
+}
+
+func TestBuildCompanyVersionFilter(t *testing.T) {
+	companyID := uuid.New()
+	seven := 7
+
+	tests := []struct {
+		name        string
+		req         ListCompanyVersionsRequest
+		expectError bool
+		expectLimit int
+		expectAfter *int
+	}{
+		{"Defaults", ListCompanyVersionsRequest{}, false, common.DefaultPageLimit, nil},
+		{"Explicit limit", ListCompanyVersionsRequest{Limit: 5}, false, 5, nil},
+		{"Valid cursor", ListCompanyVersionsRequest{Cursor: encodeCursor("7")}, false, common.DefaultPageLimit, &seven},
+		{"Cursor without version", ListCompanyVersionsRequest{Cursor: encodeCursor("latest")}, true, 0, nil},
+		{"Malformed cursor", ListCompanyVersionsRequest{Cursor: "%%%"}, true, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := buildCompanyVersionFilter(companyID, tt.req)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("buildCompanyVersionFilter() error = nil, expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("buildCompanyVersionFilter() unexpected error = %v", err)
+			}
+
+			if filter.CompanyID != companyID || filter.Limit != tt.expectLimit {
+				t.Errorf("buildCompanyVersionFilter() got CompanyID = %v, Limit = %v", filter.CompanyID, filter.Limit)
+			}
+
+			if (filter.AfterVersion == nil) != (tt.expectAfter == nil) ||
+				(filter.AfterVersion != nil && *filter.AfterVersion != *tt.expectAfter) {
+				t.Errorf("buildCompanyVersionFilter() got AfterVersion = %v, want %v", filter.AfterVersion, tt.expectAfter)
+			}
+		})
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		expectError bool
+		want        *int
+	}{
+		{"Missing", "", false, nil},
+		{"Any", "*", false, nil},
+		{"Own ETag", companyETag(3), false, func() *int { v := 3; return &v }()},
+		{"Weak ETag", `W/"3"`, true, nil},
+		{"Unquoted", "3", true, nil},
+		{"Foreign ETag", `"abc"`, true, nil},
+		{"List", `"3", "4"`, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIfMatch(tt.header)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("parseIfMatch(%q) error = nil, expected an error", tt.header)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseIfMatch(%q) unexpected error = %v", tt.header, err)
+			}
+
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("parseIfMatch(%q) got = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsNotModified(t *testing.T) {
+	etag := companyETag(3)
+	lastModified := time.Date(2024, 9, 27, 16, 59, 39, 871742000, time.UTC)
+	at := func(tm time.Time) string { return tm.Format(http.TimeFormat) }
+
+	tests := []struct {
+		name            string
+		ifNoneMatch     string
+		ifModifiedSince string
+		want            bool
+	}{
+		{"No conditions", "", "", false},
+		{"Matching ETag", etag, "", true},
+		{"Weak matching ETag", `W/"3"`, "", true},
+		{"ETag in list", `"1", "3"`, "", true},
+		{"Any ETag", "*", "", true},
+		{"Outdated ETag", companyETag(2), "", false},
+		{"Outdated ETag wins over date", companyETag(2), at(lastModified.Add(time.Hour)), false},
+		{"Same second", "", at(lastModified), true},
+		{"Modified since", "", at(lastModified.Add(-time.Second)), false},
+		{"Later date", "", at(lastModified.Add(time.Minute)), true},
+		{"Invalid date", "", "yesterday", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNotModified(tt.ifNoneMatch, tt.ifModifiedSince, etag, lastModified); got != tt.want {
+				t.Errorf("isNotModified() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBatchOperation(t *testing.T) {
+	owner := uuid.New()
+	id := uuid.New()
+	version := 2
+
+	tests := []struct {
+		name        string
+		op          BatchCompanyOperation
+		expectCode  int
+		expectType  domain.CompanyOperationType
+		expectID    uuid.UUID
+		expectField string
+	}{
+		{"Create", BatchCompanyOperation{Op: "create", Data: []byte(`{"name":"Acme","amountOfEmployees":5,"registered":true,"type":"NonProfit"}`)}, 0, domain.CompanyOperationCreate, uuid.Nil, ""},
+		{"Create unregistered", BatchCompanyOperation{Op: "create", Data: []byte(`{"name":"Acme","amountOfEmployees":5,"registered":false,"type":"NonProfit"}`)}, 0, domain.CompanyOperationCreate, uuid.Nil, ""},
+		{"Create failing validation", BatchCompanyOperation{Op: "create", Data: []byte(`{"name":"Acme","amountOfEmployees":0,"registered":true,"type":"NonProfit"}`)}, http.StatusBadRequest, "", uuid.Nil, ""},
+		{"Create without data", BatchCompanyOperation{Op: "create"}, http.StatusBadRequest, "", uuid.Nil, ""},
+		{"Create with malformed data", BatchCompanyOperation{Op: "create", Data: []byte(`[1]`)}, http.StatusBadRequest, "", uuid.Nil, ""},
+		{"Update", BatchCompanyOperation{Op: "update", ID: id.String(), Version: &version, Data: []byte(`{"amountOfEmployees":7}`)}, 0, domain.CompanyOperationUpdate, id, "amount_of_employees"},
+		{"Update failing validation", BatchCompanyOperation{Op: "update", ID: id.String(), Data: []byte(`{"name":"A name far too long"}`)}, http.StatusBadRequest, "", uuid.Nil, ""},
+		{"Update setting the status", BatchCompanyOperation{Op: "update", ID: id.String(), Data: []byte(`{"status":"dissolved"}`)}, http.StatusBadRequest, "", uuid.Nil, ""},
+		{"Update without id", BatchCompanyOperation{Op: "update", Data: []byte(`{"amountOfEmployees":7}`)}, http.StatusBadRequest, "", uuid.Nil, ""},
+		{"Update without fields", BatchCompanyOperation{Op: "update", ID: id.String(), Data: []byte(`{}`)}, http.StatusBadRequest, "", uuid.Nil, ""},
+		{"Update with null fields", BatchCompanyOperation{Op: "update", ID: id.String(), Data: []byte(`{"name":null,"type":null}`)}, http.StatusBadRequest, "", uuid.Nil, ""},
+		{"Delete", BatchCompanyOperation{Op: "delete", ID: id.String()}, 0, domain.CompanyOperationDelete, id, ""},
+		{"Delete with invalid id", BatchCompanyOperation{Op: "delete", ID: "abc"}, http.StatusBadRequest, "", uuid.Nil, ""},
+		{"Unknown operation", BatchCompanyOperation{Op: "upsert", ID: id.String()}, http.StatusBadRequest, "", uuid.Nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, appErr := parseBatchOperation(tt.op, owner)
+			if tt.expectCode != 0 {
+				if appErr == nil || appErr.Code() != tt.expectCode {
+					t.Errorf("parseBatchOperation() error = %v, want code %v", appErr, tt.expectCode)
+				}
+				return
+			}
+
+			if appErr != nil {
+				t.Fatalf("parseBatchOperation() unexpected error = %v", appErr)
+			}
+
+			if op.Type != tt.expectType {
+				t.Errorf("parseBatchOperation() type = %v, want %v", op.Type, tt.expectType)
+			}
+
+			if op.Type == domain.CompanyOperationCreate {
+				if op.Company == nil || op.Company.OwnerID == nil || *op.Company.OwnerID != owner {
+					t.Errorf("parseBatchOperation() company = %+v, want one owned by %v", op.Company, owner)
+				}
+				return
+			}
+
+			if op.ID != tt.expectID {
+				t.Errorf("parseBatchOperation() id = %v, want %v", op.ID, tt.expectID)
+			}
+
+			if _, ok := op.Updates[tt.expectField]; tt.expectField != "" && !ok {
+				t.Errorf("parseBatchOperation() updates = %v, want %v set", op.Updates, tt.expectField)
+			}
+		})
+	}
+}
+
+func TestAbortBatch(t *testing.T) {
+	results := []BatchCompanyResult{
+		{Index: 0, Op: "create", Status: http.StatusCreated, Company: &domain.Company{}},
+		{Index: 1, Op: "update", Status: http.StatusConflict, Error: "company with this name already exists"},
+		{Index: 2, Op: "delete"},
+	}
+
+	if got := abortBatch(results); got != http.StatusConflict {
+		t.Errorf("abortBatch() got = %v, want %v", got, http.StatusConflict)
+	}
+
+	for _, i := range []int{0, 2} {
+		if results[i].Status != http.StatusFailedDependency || results[i].Company != nil {
+			t.Errorf("abortBatch() result %d = %+v, want it marked as not applied", i, results[i])
+		}
+	}
+
+	if results[1].Status != http.StatusConflict {
+		t.Errorf("abortBatch() result 1 status = %v, want %v", results[1].Status, http.StatusConflict)
+	}
+
+	succeeded := []BatchCompanyResult{{Index: 0, Op: "delete", Status: http.StatusNoContent}}
+	if got := abortBatch(succeeded); got != http.StatusOK || succeeded[0].Status != http.StatusNoContent {
+		t.Errorf("abortBatch() got = %v, results = %+v, want 200 and results untouched", got, succeeded)
+	}
+}
+
+func TestHighlightName(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"Acme Anvils", "acme", "<mark>Acme</mark> Anvils"},
+		{"Banana Band", "AN", "B<mark>an</mark><mark>an</mark>a B<mark>an</mark>d"},
+		{"Acme", "akme", "Acme"},
+		{"Fish & <Chips>", "chips", "Fish &amp; &lt;<mark>Chips</mark>&gt;"},
+		{"Café Crème", "crème", "Café <mark>Crème</mark>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightName(tt.name, tt.query); got != tt.want {
+				t.Errorf("highlightName() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	snippet := "We build " + domain.SearchMatchStart + "rockets" + domain.SearchMatchStop + " & <script>"
+	want := "We build <mark>rockets</mark> &amp; &lt;script&gt;"
+
+	if got := highlightSnippet(snippet); got != want {
+		t.Errorf("highlightSnippet() got = %v, want %v", got, want)
+	}
+
+	if got := highlightSnippet(""); got != "" {
+		t.Errorf("highlightSnippet() got = %v, want empty", got)
+	}
+}
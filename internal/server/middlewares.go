@@ -1,6 +1,11 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -9,29 +14,52 @@ import (
 	"github.com/ashtishad/xm/internal/domain"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 func (s *Server) setupMiddleware() {
 	s.router.Use(gin.Recovery())
 	s.router.Use(gin.Logger())
+	s.router.Use(CorrelationIDMiddleware())
 	s.router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "If-Match", "If-None-Match", "If-Modified-Since", idempotencyKeyHeader, correlationIDHeader},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "Last-Modified", idempotentReplayedHeader, correlationIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 }
 
+// correlationIDHeader carries the correlation ID of a request in both directions.
+const correlationIDHeader = "X-Correlation-ID"
+
+// CorrelationIDMiddleware takes the correlation ID from the X-Correlation-ID request header, or
+// generates one, echoes it in the response and stores it in the request context, where it ends up
+// in the correlationid attribute of the events produced by the request.
+func CorrelationIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		correlationID := c.GetHeader(correlationIDHeader)
+		if correlationID == "" || len(correlationID) > 128 {
+			correlationID = uuid.NewString()
+		}
+
+		c.Header(correlationIDHeader, correlationID)
+		c.Request = c.Request.WithContext(domain.WithCorrelationID(c.Request.Context(), correlationID))
+		c.Next()
+	}
+}
+
 // AuthMiddleware validates the JWT token with ecdsa public key from the Authorization header,
 // extracts the user ID from claims, and fetches the corresponding user from the database.
-// The authorizedUser is then stored in the request context for use in subsequent handlers.
+// Rejects tokens without a token ID (jti) and tokens that have been revoked.
+// The authorizedUser and its tokenClaims are then stored in the request context for use in subsequent handlers.
 
 // Parameters:
 //   - userRepo: A UserRepository for fetching user data, passed as a parameter to allow for
 //     dependency injection and easier testing.
-func (s *Server) AuthMiddleware(userRepo domain.UserRepository) gin.HandlerFunc {
+//   - revocations: A TokenRevocationRepository consulted for revoked tokens.
+func (s *Server) AuthMiddleware(userRepo domain.UserRepository, revocations domain.TokenRevocationRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -55,6 +83,13 @@ func (s *Server) AuthMiddleware(userRepo domain.UserRepository) gin.HandlerFunc
 			return
 		}
 
+		jti, err := uuid.Parse(claims.ID)
+		if err != nil || claims.IssuedAt == nil {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid or expired token"})
+			c.Abort()
+			return
+		}
+
 		user, appErr := userRepo.FindBy(c.Request.Context(), common.DBColumnUUID, claims.UserID)
 		if appErr != nil {
 			c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
@@ -62,8 +97,216 @@ func (s *Server) AuthMiddleware(userRepo domain.UserRepository) gin.HandlerFunc
 			return
 		}
 
-		// Set the authorized user in the context
+		revoked, appErr := revocations.IsRevoked(c.Request.Context(), jti, user.ID, claims.IssuedAt.Time)
+		if appErr != nil {
+			c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+			c.Abort()
+			return
+		}
+
+		if revoked {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		// Set the authorized user and token claims in the context
 		c.Set("authorizedUser", user)
+		c.Set("tokenClaims", claims)
+		c.Next()
+	}
+}
+
+// RequireRole allows the request only when the authorized user has one of the given roles.
+// Must run after AuthMiddleware. The role is read from the user loaded by AuthMiddleware rather
+// than from the token, so role changes apply immediately.
+func (s *Server) RequireRole(roles ...domain.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := authorizedUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Missing authorization"})
+			c.Abort()
+			return
+		}
+
+		for _, role := range roles {
+			if user.Role == role {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "insufficient role"})
+		c.Abort()
+	}
+}
+
+// RequirePermission allows the request only when the authorized user's role grants the permission.
+// Must run after AuthMiddleware.
+func (s *Server) RequirePermission(permission domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := authorizedUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Missing authorization"})
+			c.Abort()
+			return
+		}
+
+		if !user.Role.Can(permission) {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "missing permission " + string(permission)})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Idempotency headers: the client supplied key, and the marker set on replayed responses.
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// replayedResponseHeaders lists the response headers stored with an idempotency key and replayed with the body.
+var replayedResponseHeaders = []string{"Content-Type", "Cache-Control", "ETag", "Last-Modified", "Location"}
+
+// IdempotencyMiddleware makes requests carrying an Idempotency-Key header safe to retry.
+// The first request with a key runs normally and its response is stored for ttl; retries with the same key
+// and an identical method, path and body get that response replayed, marked with Idempotent-Replayed: true.
+// Reusing a key for a different request is rejected with 422, and a retry arriving while the first request
+// is still running gets 409. Server errors are not stored, so the request can be retried with the same key.
+// Keys are scoped per user, so this must run after AuthMiddleware.
+func (s *Server) IdempotencyMiddleware(repo domain.IdempotencyKeyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Idempotency-Key must not exceed 255 characters"})
+			c.Abort()
+			return
+		}
+
+		user, ok := authorizedUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Missing authorization"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		stored, acquired, appErr := repo.Acquire(c.Request.Context(), &domain.IdempotencyKey{
+			UserID:      user.ID,
+			Key:         key,
+			RequestHash: requestHash(c.Request.Method, c.Request.URL.Path, body),
+			ExpiresAt:   time.Now().Add(ttl),
+		})
+		if appErr != nil {
+			c.JSON(appErr.Code(), ErrorResponse{Error: appErr.Error()})
+			c.Abort()
+			return
+		}
+
+		if !acquired {
+			replayIdempotentResponse(c, stored, requestHash(c.Request.Method, c.Request.URL.Path, body))
+			c.Abort()
+			return
+		}
+
+		// The outcome must be recorded even when the client has gone away in the meantime.
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		// Unless the response is stored, the key is released so the request can be retried. The deferred
+		// release also runs when the handler panics, e.g. with http.ErrAbortHandler on a client abort.
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+
+			if appErr := repo.Release(ctx, user.ID, key); appErr != nil {
+				s.Logger.Error("failed to release idempotency key", "err", appErr.DetailedError())
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
 		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		stored.StatusCode = recorder.Status()
+		stored.ResponseBody = recorder.body.Bytes()
+		stored.ResponseHeaders = make(map[string]string)
+		for _, header := range replayedResponseHeaders {
+			if value := recorder.Header().Get(header); value != "" {
+				stored.ResponseHeaders[header] = value
+			}
+		}
+
+		if appErr := repo.Complete(ctx, stored); appErr != nil {
+			s.Logger.Error("failed to store idempotent response", "err", appErr.DetailedError())
+			return
+		}
+		completed = true
+	}
+}
+
+// replayIdempotentResponse answers a retry with the stored response of the original request,
+// or with an error when the original request is still running or the key was used for another request.
+func replayIdempotentResponse(c *gin.Context, stored *domain.IdempotencyKey, hash string) {
+	if stored.RequestHash != hash {
+		c.JSON(http.StatusUnprocessableEntity, ErrorResponse{Error: "Idempotency-Key has already been used for a different request"})
+		return
+	}
+
+	if !stored.Completed() {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "request with this Idempotency-Key is being processed, retry later"})
+		return
 	}
+
+	for header, value := range stored.ResponseHeaders {
+		c.Header(header, value)
+	}
+	c.Header(idempotentReplayedHeader, "true")
+	c.Status(stored.StatusCode)
+	_, _ = c.Writer.Write(stored.ResponseBody)
+}
+
+// requestHash fingerprints a request, so a reused idempotency key can be told apart from a retry.
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder copies the response body while it is written to the client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
 }
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xm/common"
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeIdempotencyKeyRepository keeps idempotency keys in memory, ignoring expiry.
+type fakeIdempotencyKeyRepository struct {
+	keys map[string]*domain.IdempotencyKey
+}
+
+func (r *fakeIdempotencyKeyRepository) Acquire(_ context.Context, key *domain.IdempotencyKey) (*domain.IdempotencyKey, bool, common.AppError) {
+	if existing, ok := r.keys[key.Key]; ok {
+		stored := *existing
+		return &stored, false, nil
+	}
+
+	stored := *key
+	r.keys[key.Key] = &stored
+	return key, true, nil
+}
+
+func (r *fakeIdempotencyKeyRepository) Complete(_ context.Context, key *domain.IdempotencyKey) common.AppError {
+	stored := *key
+	r.keys[key.Key] = &stored
+	return nil
+}
+
+func (r *fakeIdempotencyKeyRepository) Release(_ context.Context, _ int, key string) common.AppError {
+	delete(r.keys, key)
+	return nil
+}
+
+func (r *fakeIdempotencyKeyRepository) DeleteExpired(_ context.Context) (int64, common.AppError) {
+	return 0, nil
+}
+
+func newIdempotencyTestRouter(repo domain.IdempotencyKeyRepository, status *int, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	s := &Server{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	router := gin.New()
+	router.POST("/companies",
+		func(c *gin.Context) { c.Set("authorizedUser", &domain.User{ID: 1}) },
+		s.IdempotencyMiddleware(repo, time.Hour),
+		func(c *gin.Context) {
+			*calls++
+			body, _ := io.ReadAll(c.Request.Body)
+			c.Header("ETag", companyETag(*calls))
+			c.Data(*status, "application/json", body)
+		},
+	)
+
+	return router
+}
+
+func postWithKey(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/companies", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	repo := &fakeIdempotencyKeyRepository{keys: make(map[string]*domain.IdempotencyKey)}
+	status, calls := http.StatusCreated, 0
+	router := newIdempotencyTestRouter(repo, &status, &calls)
+
+	first := postWithKey(router, "key-1", `{"name":"TechCorp"}`)
+	if first.Code != http.StatusCreated || calls != 1 {
+		t.Fatalf("first request got status = %v, calls = %v", first.Code, calls)
+	}
+
+	retry := postWithKey(router, "key-1", `{"name":"TechCorp"}`)
+	if retry.Code != http.StatusCreated || calls != 1 {
+		t.Errorf("retry got status = %v, calls = %v, want the stored 201 without calling the handler", retry.Code, calls)
+	}
+
+	if retry.Body.String() != first.Body.String() || retry.Header().Get("ETag") != first.Header().Get("ETag") {
+		t.Errorf("retry got body = %s, ETag = %s, want the original response", retry.Body, retry.Header().Get("ETag"))
+	}
+
+	if retry.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Errorf("retry is missing the %s header", idempotentReplayedHeader)
+	}
+
+	if reuse := postWithKey(router, "key-1", `{"name":"OtherCorp"}`); reuse.Code != http.StatusUnprocessableEntity {
+		t.Errorf("key reuse with a different body got status = %v, want 422", reuse.Code)
+	}
+
+	postWithKey(router, "", `{"name":"TechCorp"}`)
+	postWithKey(router, "", `{"name":"TechCorp"}`)
+	if calls != 3 {
+		t.Errorf("requests without a key got calls = %v, want 3", calls)
+	}
+}
+
+func TestIdempotencyMiddleware_InProgressAndServerErrors(t *testing.T) {
+	repo := &fakeIdempotencyKeyRepository{keys: map[string]*domain.IdempotencyKey{
+		"running": {UserID: 1, Key: "running", RequestHash: requestHash(http.MethodPost, "/companies", []byte(`{}`))},
+	}}
+	status, calls := http.StatusInternalServerError, 0
+	router := newIdempotencyTestRouter(repo, &status, &calls)
+
+	if w := postWithKey(router, "running", `{}`); w.Code != http.StatusConflict || calls != 0 {
+		t.Errorf("retry of a running request got status = %v, calls = %v, want 409", w.Code, calls)
+	}
+
+	postWithKey(router, "failing", `{}`)
+	if _, stored := repo.keys["failing"]; stored {
+		t.Errorf("server error response should not be stored")
+	}
+
+	status = http.StatusCreated
+	if w := postWithKey(router, "failing", `{}`); w.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("retry after a server error got status = %v, calls = %v, want the handler to run again", w.Code, calls)
+	}
+
+	if w := postWithKey(router, strings.Repeat("k", 256), `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("overlong key got status = %v, want 400", w.Code)
+	}
+}
+
+func TestIdempotencyMiddleware_ReleasesKeyOnPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	repo := &fakeIdempotencyKeyRepository{keys: make(map[string]*domain.IdempotencyKey)}
+
+	router := gin.New()
+	router.POST("/companies",
+		func(c *gin.Context) {
+			defer func() {
+				if r := recover(); r != http.ErrAbortHandler {
+					t.Errorf("handler got panic = %v, want %v", r, http.ErrAbortHandler)
+				}
+			}()
+			c.Next()
+		},
+		func(c *gin.Context) { c.Set("authorizedUser", &domain.User{ID: 1}) },
+		s.IdempotencyMiddleware(repo, time.Hour),
+		func(c *gin.Context) { panic(http.ErrAbortHandler) },
+	)
+
+	postWithKey(router, "aborted", `{}`)
+	if _, held := repo.keys["aborted"]; held {
+		t.Errorf("key of a panicking request should be released")
+	}
+}
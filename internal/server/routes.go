@@ -1,6 +1,9 @@
 package server
 
 import (
+	"net/http"
+	"strings"
+
 	"github.com/ashtishad/xm/internal/domain"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -16,28 +19,125 @@ func (s *Server) setupRoutes() {
 	eventRepo := domain.NewEventRepository(s.db, s.Logger)
 	userRepo := domain.NewUserRepository(s.db, s.Logger)
 	companyRepo := domain.NewCompanyRepository(s.db, s.Logger, eventRepo)
+	companyDetailRepo := domain.NewCompanyDetailRepository(s.db, s.Logger, eventRepo)
+	companyVersionRepo := domain.NewCompanyVersionRepository(s.db, s.Logger)
+	companyTypeRepo := domain.NewCachedCompanyTypeRepository(
+		domain.NewCompanyTypeRepository(s.db, s.Logger),
+		s.Config.CompanyTypes.CacheTTL,
+	)
+	refreshTokenRepo := domain.NewRefreshTokenRepository(s.db, s.Logger)
+	idempotencyKeyRepo := domain.NewIdempotencyKeyRepository(s.db, s.Logger)
+	revocationRepo := domain.NewCachedTokenRevocationRepository(
+		domain.NewTokenRevocationRepository(s.db, s.Logger),
+		s.Config.Auth.RevocationCacheTTL,
+	)
+
+	authMiddleware := s.AuthMiddleware(userRepo, revocationRepo)
 
-	s.registerAuthRoutes(api, userRepo)
-	s.registerCompanyRoutes(api, companyRepo, userRepo)
+	s.registerAuthRoutes(api, authMiddleware, userRepo, refreshTokenRepo, revocationRepo)
+	s.registerCompanyRoutes(api, authMiddleware, companyRepo, companyDetailRepo, companyTypeRepo, companyVersionRepo, eventRepo,
+		idempotencyKeyRepo)
+	s.registerCompanyTypeRoutes(api, authMiddleware, companyTypeRepo)
 }
 
-func (s *Server) registerAuthRoutes(rg *gin.RouterGroup, userRepo domain.UserRepository) {
-	authHandler := NewAuthHandler(userRepo, s.Config.JWT, s.Logger)
+func (s *Server) registerAuthRoutes(rg *gin.RouterGroup, authMiddleware gin.HandlerFunc, userRepo domain.UserRepository,
+	refreshTokenRepo domain.RefreshTokenRepository, revocationRepo domain.TokenRevocationRepository) {
+	authHandler := NewAuthHandler(userRepo, refreshTokenRepo, revocationRepo, s.Config.JWT, s.Logger)
 
 	rg.POST("/register", authHandler.Register)
 	rg.POST("/login", authHandler.Login)
+	rg.POST("/token/refresh", authHandler.RefreshToken)
+	rg.POST("/logout", authMiddleware, authHandler.Logout)
+
+	users := rg.Group("/users")
+
+	users.Use(authMiddleware)
+	{
+		users.POST("/:id/revoke-tokens", authHandler.RevokeUserTokens)
+		users.PUT("/:id/role", s.RequirePermission(domain.PermissionUsersManage), authHandler.UpdateUserRole)
+	}
 }
 
-func (s *Server) registerCompanyRoutes(rg *gin.RouterGroup, companyRepo domain.CompanyRepository, userRepo domain.UserRepository) {
-	companyHandler := NewCompanyHandler(companyRepo, s.Logger)
+func (s *Server) registerCompanyRoutes(rg *gin.RouterGroup, authMiddleware gin.HandlerFunc, companyRepo domain.CompanyRepository,
+	companyDetailRepo domain.CompanyDetailRepository, companyTypeRepo domain.CompanyTypeRepository,
+	companyVersionRepo domain.CompanyVersionRepository, eventRepo domain.EventRepository, idempotencyKeyRepo domain.IdempotencyKeyRepository) {
+	companyHandler := NewCompanyHandler(companyRepo, companyDetailRepo, companyTypeRepo, companyVersionRepo, eventRepo, s.Logger)
 
 	companies := rg.Group("/companies")
 
-	companies.Use(s.AuthMiddleware(userRepo))
+	companies.Use(authMiddleware)
 	{
-		companies.POST("/", companyHandler.CreateCompany)
-		companies.GET("/:id", companyHandler.GetCompany)
-		companies.PATCH("/:id", companyHandler.UpdateCompany)
-		companies.DELETE("/:id", companyHandler.DeleteCompany)
+		canRead := s.RequirePermission(domain.PermissionCompaniesRead)
+		canWrite := s.RequirePermission(domain.PermissionCompaniesWrite)
+		canDelete := s.RequirePermission(domain.PermissionCompaniesDelete)
+		idempotent := s.IdempotencyMiddleware(idempotencyKeyRepo, s.Config.Idempotency.KeyTTL)
+
+		companies.POST("/", canWrite, idempotent, companyHandler.CreateCompany)
+		companies.GET("/", canRead, companyHandler.ListCompanies)
+		companies.POST("/import", canWrite, companyHandler.ImportCompanies)
+		companies.GET("/export", canRead, companyHandler.ExportCompanies)
+		companies.GET("/search", canRead, companyHandler.SearchCompanies)
+		companies.GET("/name-availability", canRead, companyHandler.CheckNameAvailability)
+		companies.GET("/:id", canRead, companyHandler.GetCompany)
+		companies.PATCH("/:id", canWrite, companyHandler.UpdateCompany)
+		companies.DELETE("/:id", canDelete, companyHandler.DeleteCompany)
+		companies.GET("/:id/events", canRead, companyHandler.ListCompanyEvents)
+		companies.GET("/:id/versions", canRead, companyHandler.ListCompanyVersions)
+		companies.GET("/:id/versions/diff", canRead, companyHandler.DiffCompanyVersions)
+		companies.GET("/:id/versions/:version", canRead, companyHandler.GetCompanyVersion)
+		companies.POST("/:id/transfer-ownership", canWrite, companyHandler.TransferOwnership)
+		companies.POST("/:id/transition", canWrite, companyHandler.TransitionCompanyStatus)
+		companies.POST("/:id/restore", s.RequireRole(domain.UserRoleAdmin), companyHandler.RestoreCompany)
+		companies.PUT("/:id/parent", canWrite, companyHandler.SetCompanyParent)
+		companies.GET("/:id/subsidiaries", canRead, companyHandler.ListSubsidiaries)
+		companies.GET("/:id/ancestors", canRead, companyHandler.ListAncestors)
+		companies.GET("/:id/addresses", canRead, companyHandler.ListAddresses)
+		companies.POST("/:id/addresses", canWrite, companyHandler.CreateAddress)
+		companies.PATCH("/:id/addresses/:addressId", canWrite, companyHandler.UpdateAddress)
+		companies.DELETE("/:id/addresses/:addressId", canDelete, companyHandler.DeleteAddress)
+		companies.GET("/:id/contacts", canRead, companyHandler.ListContacts)
+		companies.POST("/:id/contacts", canWrite, companyHandler.CreateContact)
+		companies.PATCH("/:id/contacts/:contactId", canWrite, companyHandler.UpdateContact)
+		companies.DELETE("/:id/contacts/:contactId", canDelete, companyHandler.DeleteContact)
+		companies.GET("/:id/identifiers", canRead, companyHandler.ListIdentifiers)
+		companies.POST("/:id/identifiers", canWrite, companyHandler.CreateIdentifier)
+		companies.DELETE("/:id/identifiers/:identifierId", canDelete, companyHandler.DeleteIdentifier)
+
+		rg.POST("/companies:method", authMiddleware, canWrite, idempotent, customMethods("method", map[string]gin.HandlerFunc{
+			"batch": companyHandler.BatchCompanies,
+		}))
+	}
+}
+
+func (s *Server) registerCompanyTypeRoutes(rg *gin.RouterGroup, authMiddleware gin.HandlerFunc, companyTypeRepo domain.CompanyTypeRepository) {
+	companyTypeHandler := NewCompanyTypeHandler(companyTypeRepo, s.Logger)
+
+	companyTypes := rg.Group("/company-types")
+
+	companyTypes.Use(authMiddleware)
+	{
+		canRead := s.RequirePermission(domain.PermissionCompaniesRead)
+		adminOnly := s.RequireRole(domain.UserRoleAdmin)
+
+		companyTypes.GET("/", canRead, companyTypeHandler.ListCompanyTypes)
+		companyTypes.GET("/:name", canRead, companyTypeHandler.GetCompanyType)
+		companyTypes.POST("/", adminOnly, companyTypeHandler.CreateCompanyType)
+		companyTypes.PUT("/:name", adminOnly, companyTypeHandler.UpdateCompanyType)
+		companyTypes.DELETE("/:name", adminOnly, companyTypeHandler.DeleteCompanyType)
+	}
+}
+
+// customMethods dispatches custom methods such as POST /companies:batch to their handler.
+// Gin reads the colon as the start of a path parameter, so the parameter holds the method name
+// prefixed with the colon; unknown methods are answered with 404.
+func customMethods(param string, handlers map[string]gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		handler, ok := handlers[strings.TrimPrefix(c.Param(param), ":")]
+		if !ok {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Not found"})
+			return
+		}
+
+		handler(c)
 	}
 }
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/ashtishad/xm/common"
 	"github.com/ashtishad/xm/infra/postgres"
+	"github.com/ashtishad/xm/internal/domain"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,6 +21,12 @@ type Server struct {
 	Logger *slog.Logger
 	server *http.Server
 	Config *common.AppConfig
+
+	workers        []worker
+	workersWG      sync.WaitGroup
+	workersMu      sync.Mutex
+	workersStopped bool
+	stopWorkersFn  context.CancelFunc
 }
 
 func NewServer(ctx context.Context) (*Server, error) {
@@ -30,6 +38,10 @@ func NewServer(ctx context.Context) (*Server, error) {
 		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	if err := domain.SetEventSchemaBase(cfg.Events.SchemaBaseURI); err != nil {
+		return nil, err
+	}
+
 	db, err := postgres.NewConnection(ctx, logger, cfg.DB)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create database connection pool: %w", err)
@@ -59,16 +71,25 @@ func NewServer(ctx context.Context) (*Server, error) {
 	s.setupMiddleware()
 	s.setupRoutes()
 
+	if err := s.setupWorkers(); err != nil {
+		return nil, err
+	}
+
 	s.Logger.Info(fmt.Sprintf("Swagger Specs available at %s/swagger/index.html", s.server.Addr))
 
 	return s, nil
 }
 
+// Start launches the background workers and serves HTTP until the server is shut down.
 func (s *Server) Start(ctx context.Context) error {
+	s.startWorkers(ctx)
 	return s.server.ListenAndServe()
 }
 
+// Shutdown stops the background workers, then the database connection and the HTTP server.
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopWorkers()
+
 	if err := s.db.Close(); err != nil {
 		s.Logger.Error("failed to close database connection", "error", err)
 	}
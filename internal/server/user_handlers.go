@@ -16,24 +16,29 @@ import (
 )
 
 type UserHandler struct {
-	userRepo   domain.UserRepository
-	JWTManager *security.JWTManager
-	l          *slog.Logger
+	userRepo         domain.UserRepository
+	refreshTokenRepo domain.RefreshTokenRepository
+	revocationRepo   domain.TokenRevocationRepository
+	JWTManager       *security.JWTManager
+	l                *slog.Logger
 }
 
-func NewAuthHandler(userRepo domain.UserRepository, jwtManager *security.JWTManager, logger *slog.Logger) *UserHandler {
+func NewAuthHandler(userRepo domain.UserRepository, refreshTokenRepo domain.RefreshTokenRepository,
+	revocationRepo domain.TokenRevocationRepository, jwtManager *security.JWTManager, logger *slog.Logger) *UserHandler {
 	return &UserHandler{
-		userRepo:   userRepo,
-		JWTManager: jwtManager,
-		l:          logger,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		revocationRepo:   revocationRepo,
+		JWTManager:       jwtManager,
+		l:                logger,
 	}
 }
 
 // Register godoc
 // @Summary Register a new user
 // @Description Hashes password using bcrypt before storage.
-// @Description Generates JWT access token using ECDSA encryption.
-// @Description Sets HTTP-only cookie with access token.
+// @Description Generates JWT access token using ECDSA encryption and a refresh token.
+// @Description Sets HTTP-only cookies with access and refresh tokens.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -68,6 +73,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 		Name:         req.Name,
 		PasswordHash: string(hashedPass),
 		Status:       domain.UserStatusActive,
+		Role:         domain.UserRoleViewer,
 		CreatedAt:    &now,
 		UpdatedAt:    &now,
 	}
@@ -78,19 +84,11 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	accessToken, err := h.JWTManager.GenerateAccessToken(
-		createdUser.UUID.String(),
-		h.JWTManager.AccessExp,
-	)
-
-	if err != nil {
-		h.l.Error("failed to generate access token", "err", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{common.ErrUnexpectedServer})
+	if appErr := h.issueTokens(ctx, c, createdUser); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{appErr.Error()})
 		return
 	}
 
-	c.SetCookie("accessToken", accessToken, int(h.JWTManager.AccessExp.Seconds()), "/", "", true, true)
-
 	resp := RegisterUserResponse{
 		User: *createdUser,
 	}
@@ -101,8 +99,8 @@ func (h *UserHandler) Register(c *gin.Context) {
 // Login godoc
 // @Summary Authenticate a user and provide access token
 // @Description Verifies password using bcrypt comparison.
-// @Description Generates new JWT access token using ECDSA encryption.
-// @Description Sets HTTP-only cookie with new access token.
+// @Description Generates new JWT access token using ECDSA encryption and a new refresh token.
+// @Description Sets HTTP-only cookies with the new access and refresh tokens.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -131,24 +129,281 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if appErr := h.issueTokens(c.Request.Context(), c, user); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{appErr.Error()})
+		return
+	}
+
+	resp := LoginResponse{
+		User: *user,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RefreshToken godoc
+// @Summary Exchange a refresh token for new tokens
+// @Description Rotates the refresh token: the presented token is invalidated and a new one is issued
+// @Description together with a new JWT access token, both set as HTTP-only cookies.
+// @Description The token is read from the request body, or from the refreshToken cookie when the body omits it.
+// @Description Reusing an already rotated token revokes every token descending from the same login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body RefreshTokenRequest false "Refresh token"
+// @Success 200 {object} RefreshTokenResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /token/refresh [post]
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+			return
+		}
+	}
+
+	presented := req.RefreshToken
+	if presented == "" {
+		presented, _ = c.Cookie("refreshToken")
+	}
+
+	if presented == "" {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Missing refresh token"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.TimeOutRefreshToken)
+	defer cancel()
+
+	refreshToken, next, err := h.newRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{common.ErrUnexpectedServer})
+		return
+	}
+
+	rotated, appErr := h.refreshTokenRepo.Rotate(ctx, security.HashRefreshToken(presented), next)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{appErr.Error()})
+		return
+	}
+
+	user, appErr := h.userRepo.FindBy(ctx, common.DBColumnID, rotated.UserID)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{appErr.Error()})
+		return
+	}
+
+	if appErr := h.setAuthCookies(c, user, refreshToken); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, RefreshTokenResponse{User: *user})
+}
+
+// Logout godoc
+// @Summary Log out the current session
+// @Description Revokes the access token used for this request and the refresh token family of the session,
+// @Description then clears both token cookies.
+// @Description The refresh token is read from the request body, or from the refreshToken cookie when the body omits it.
+// @Description Refresh tokens of other users are left untouched.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body RefreshTokenRequest false "Refresh token"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /logout [post]
+func (h *UserHandler) Logout(c *gin.Context) {
+	var req RefreshTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+			return
+		}
+	}
+
+	user, _ := authorizedUser(c)
+	claims, ok := tokenClaims(c)
+	if user == nil || !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Missing authorization"})
+		return
+	}
+
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil || claims.ExpiresAt == nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid or expired token"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if appErr := h.revocationRepo.RevokeToken(ctx, jti, user.ID, claims.ExpiresAt.Time); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{appErr.Error()})
+		return
+	}
+
+	refreshToken := req.RefreshToken
+	if refreshToken == "" {
+		refreshToken, _ = c.Cookie("refreshToken")
+	}
+
+	if refreshToken != "" {
+		if appErr := h.refreshTokenRepo.RevokeFamily(ctx, user.ID, security.HashRefreshToken(refreshToken)); appErr != nil {
+			c.JSON(appErr.Code(), ErrorResponse{appErr.Error()})
+			return
+		}
+	}
+
+	c.SetCookie("accessToken", "", -1, "/", "", true, true)
+	c.SetCookie("refreshToken", "", -1, "/api", "", true, true)
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeUserTokens godoc
+// @Summary Revoke all tokens of a user
+// @Description Revokes every access and refresh token issued to the user so far, ending all of their sessions.
+// @Description Users may revoke their own tokens; revoking the tokens of another user needs the users:manage permission.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /users/{id}/revoke-tokens [post]
+func (h *UserHandler) RevokeUserTokens(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	caller, ok := authorizedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Missing authorization"})
+		return
+	}
+
+	if caller.UUID != id && !caller.Role.Can(domain.PermissionUsersManage) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "not allowed to revoke tokens of another user"})
+		return
+	}
+
+	user, appErr := h.userRepo.FindBy(c.Request.Context(), common.DBColumnUUID, id)
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{appErr.Error()})
+		return
+	}
+
+	if appErr := h.revocationRepo.RevokeAllForUser(c.Request.Context(), user.ID); appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{appErr.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UpdateUserRole godoc
+// @Summary Change the role of a user
+// @Description Assigns admin, editor or viewer to a user. Requires the users:manage permission.
+// @Description Takes effect on the user's next request; tokens already issued keep the old role claim.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User ID"
+// @Param input body UpdateUserRoleRequest true "New role"
+// @Success 200 {object} domain.User
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /users/{id}/role [put]
+func (h *UserHandler) UpdateUserRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	var req UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(common.ErrInvalidRequest, "err", formatValidationError(err))
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	user, appErr := h.userRepo.UpdateRole(c.Request.Context(), id, domain.UserRole(req.Role))
+	if appErr != nil {
+		c.JSON(appErr.Code(), ErrorResponse{appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// issueTokens starts a new refresh token family for user and sets the access and refresh token cookies.
+func (h *UserHandler) issueTokens(ctx context.Context, c *gin.Context, user *domain.User) common.AppError {
+	refreshToken, record, err := h.newRefreshToken()
+	if err != nil {
+		return common.NewInternalServerError("failed to generate refresh token", err)
+	}
+
+	record.UserID = user.ID
+	record.FamilyID = uuid.New()
+
+	if appErr := h.refreshTokenRepo.Create(ctx, record); appErr != nil {
+		return appErr
+	}
+
+	return h.setAuthCookies(c, user, refreshToken)
+}
+
+// newRefreshToken generates a refresh token, returning it along with the record to store.
+func (h *UserHandler) newRefreshToken() (string, *domain.RefreshToken, error) {
+	token, hash, err := security.GenerateRefreshToken()
+	if err != nil {
+		h.l.Error("failed to generate refresh token", "err", err)
+		return "", nil, err
+	}
+
+	return token, &domain.RefreshToken{
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(h.JWTManager.RefreshExp),
+	}, nil
+}
+
+// setAuthCookies generates an access token for user and sets it, along with refreshToken, as HTTP-only cookies.
+// The refresh token cookie is scoped to /api so it is also sent to the token endpoints.
+func (h *UserHandler) setAuthCookies(c *gin.Context, user *domain.User, refreshToken string) common.AppError {
 	accessToken, err := h.JWTManager.GenerateAccessToken(
 		user.UUID.String(),
+		string(user.Role),
 		h.JWTManager.AccessExp,
 	)
 
 	if err != nil {
 		h.l.Error("failed to generate access token", "err", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{common.ErrUnexpectedServer})
-		return
+		return common.NewInternalServerError("failed to generate access token", err)
 	}
 
 	c.SetCookie("accessToken", accessToken, int(h.JWTManager.AccessExp.Seconds()), "/", "", true, true)
+	c.SetCookie("refreshToken", refreshToken, int(h.JWTManager.RefreshExp.Seconds()), "/api", "", true, true)
 
-	resp := LoginResponse{
-		User: *user,
-	}
-
-	c.JSON(http.StatusOK, resp)
+	return nil
 }
 
 func verifyPassword(hashedPassword, plainPassword string) bool {
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ashtishad/xm/internal/domain"
+	"github.com/ashtishad/xm/internal/outbox"
+	"github.com/ashtishad/xm/internal/purge"
+)
+
+// worker is a background job that runs until its context is canceled.
+type worker interface {
+	Run(ctx context.Context)
+}
+
+// setupWorkers builds the background workers enabled by the configuration.
+// The outbox relay is only started when Kafka brokers are configured, and the company purger
+// only when a retention period is set. Expired idempotency keys, refresh tokens and token revocations are always cleaned up.
+func (s *Server) setupWorkers() error {
+	if err := s.setupOutboxRelay(); err != nil {
+		return err
+	}
+
+	s.workers = append(s.workers,
+		purge.NewExpiredPurger("idempotency keys", domain.NewIdempotencyKeyRepository(s.db, s.Logger), s.Config.Purge.Interval, s.Logger),
+		purge.NewExpiredPurger("refresh tokens", domain.NewRefreshTokenRepository(s.db, s.Logger), s.Config.Purge.Interval, s.Logger),
+		purge.NewExpiredPurger("token revocations", domain.NewTokenRevocationRepository(s.db, s.Logger), s.Config.Purge.Interval, s.Logger),
+	)
+
+	if s.Config.Purge.Retention <= 0 {
+		s.Logger.Info("PURGE_RETENTION_DAYS is 0, company purger disabled; deleted companies are kept")
+		return nil
+	}
+
+	eventRepo := domain.NewEventRepository(s.db, s.Logger)
+	purger := purge.NewCompanyPurger(
+		domain.NewCompanyRepository(s.db, s.Logger, eventRepo),
+		purge.PurgerConfig{
+			Retention: s.Config.Purge.Retention,
+			Interval:  s.Config.Purge.Interval,
+			BatchSize: s.Config.Purge.BatchSize,
+		},
+		s.Logger,
+	)
+
+	s.workers = append(s.workers, purger)
+	return nil
+}
+
+func (s *Server) setupOutboxRelay() error {
+	if len(s.Config.Outbox.KafkaBrokers) == 0 {
+		s.Logger.Warn("KAFKA_BROKERS not set, outbox relay disabled; events stay in the events table")
+		return nil
+	}
+
+	publisher, err := outbox.NewKafkaPublisher(s.Config.Outbox.KafkaBrokers, s.Config.Outbox.KafkaTopic)
+	if err != nil {
+		return fmt.Errorf("failed to create kafka publisher: %w", err)
+	}
+
+	relay := outbox.NewRelay(
+		domain.NewEventRepository(s.db, s.Logger),
+		publisher,
+		outbox.RelayConfig{
+			PollInterval: s.Config.Outbox.PollInterval,
+			BatchSize:    s.Config.Outbox.BatchSize,
+		},
+		s.Logger,
+	)
+
+	s.workers = append(s.workers, relay)
+	return nil
+}
+
+// startWorkers runs every worker in its own goroutine; stopWorkers cancels and waits for them.
+// Start and Shutdown run on different goroutines, so both hold workersMu while touching worker state.
+func (s *Server) startWorkers(ctx context.Context) {
+	s.workersMu.Lock()
+	defer s.workersMu.Unlock()
+
+	if s.workersStopped {
+		return
+	}
+
+	ctx, s.stopWorkersFn = context.WithCancel(ctx)
+
+	for _, w := range s.workers {
+		s.workersWG.Add(1)
+		go func() {
+			defer s.workersWG.Done()
+			w.Run(ctx)
+		}()
+	}
+}
+
+func (s *Server) stopWorkers() {
+	s.workersMu.Lock()
+	s.workersStopped = true
+	if s.stopWorkersFn != nil {
+		s.stopWorkersFn()
+	}
+	s.workersMu.Unlock()
+
+	s.workersWG.Wait()
+}
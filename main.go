@@ -19,6 +19,13 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if handled, err := runCommand(ctx, os.Args[1:]); handled {
+		if err != nil {
+			log.Fatalf("%s failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
 	srv, err := server.NewServer(ctx)
 	if err != nil {
 		log.Fatalf("failed to create server: %v", err)
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_events_unpublished;
+
+ALTER TABLE events
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS published_at;
@@ -0,0 +1,11 @@
+ALTER TABLE events
+    ADD COLUMN published_at TIMESTAMPTZ,
+    ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN last_error TEXT;
+
+-- Events recorded before the outbox existed are history, not pending deliveries
+UPDATE events SET published_at = created_at;
+
+-- Partial index for the outbox relay polling due, unpublished events
+CREATE INDEX idx_events_unpublished ON events (next_attempt_at, created_at) WHERE published_at IS NULL;
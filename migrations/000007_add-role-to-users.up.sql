@@ -0,0 +1,6 @@
+CREATE TYPE user_role AS ENUM ('admin', 'editor', 'viewer');
+
+ALTER TABLE users ADD COLUMN role user_role NOT NULL DEFAULT 'viewer';
+
+-- Existing users could already create, update and delete companies, keep them as editors
+UPDATE users SET role = 'editor';
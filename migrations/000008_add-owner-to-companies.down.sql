@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_companies_owner_id;
+
+ALTER TABLE companies DROP COLUMN IF EXISTS owner_id;
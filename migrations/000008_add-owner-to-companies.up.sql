@@ -0,0 +1,5 @@
+-- Creating user of a company; NULL for companies created before ownership was tracked
+ALTER TABLE companies ADD COLUMN owner_id UUID REFERENCES users(uuid) ON DELETE SET NULL;
+
+-- Partial index for listing a user's non-deleted companies in id order
+CREATE INDEX idx_companies_owner_id ON companies (owner_id, id) WHERE deleted_at IS NULL;
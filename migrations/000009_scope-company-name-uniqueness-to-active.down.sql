@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_companies_name_lower;
+
+CREATE UNIQUE INDEX idx_companies_name_lower ON companies (LOWER(name));
+
+ALTER TABLE companies ADD CONSTRAINT companies_name_key UNIQUE (name);
@@ -0,0 +1,6 @@
+-- Names of soft-deleted companies may be reused; uniqueness only applies to active companies
+ALTER TABLE companies DROP CONSTRAINT IF EXISTS companies_name_key;
+
+DROP INDEX IF EXISTS idx_companies_name_lower;
+
+CREATE UNIQUE INDEX idx_companies_name_lower ON companies (LOWER(name)) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_events_aggregate_id;
+
+ALTER TABLE events DROP COLUMN IF EXISTS aggregate_id;
@@ -0,0 +1,9 @@
+-- ID of the entity an event belongs to; every event so far carries the company as its payload
+ALTER TABLE events ADD COLUMN aggregate_id UUID;
+
+UPDATE events SET aggregate_id = (data->>'id')::uuid;
+
+ALTER TABLE events ALTER COLUMN aggregate_id SET NOT NULL;
+
+-- Index for reading the event timeline of a single entity
+CREATE INDEX idx_events_aggregate_id ON events (aggregate_id, created_at, id);
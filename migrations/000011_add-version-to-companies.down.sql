@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS increment_company_version_trigger ON companies;
+
+DROP FUNCTION IF EXISTS increment_company_version();
+
+ALTER TABLE companies DROP COLUMN IF EXISTS version;
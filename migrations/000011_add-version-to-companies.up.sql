@@ -0,0 +1,16 @@
+-- Version of a company row for optimistic concurrency, exposed as the ETag of the company
+ALTER TABLE companies ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- Every update of a company, whichever code path issues it, produces a new version
+CREATE OR REPLACE FUNCTION increment_company_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER increment_company_version_trigger
+BEFORE UPDATE ON companies
+FOR EACH ROW
+EXECUTE FUNCTION increment_company_version();
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_companies_description_fts;
+DROP INDEX IF EXISTS idx_companies_name_trgm;
+DROP EXTENSION IF EXISTS pg_trgm;
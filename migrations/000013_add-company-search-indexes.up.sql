@@ -0,0 +1,9 @@
+-- Trigram similarity on company names
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Serves fuzzy (%) and substring (ILIKE) name matches of the search endpoint
+CREATE INDEX idx_companies_name_trgm ON companies USING GIN (name gin_trgm_ops) WHERE deleted_at IS NULL;
+
+-- Full-text search on descriptions; queries must use the same expression to use the index
+CREATE INDEX idx_companies_description_fts ON companies
+    USING GIN (to_tsvector('english', COALESCE(description, ''))) WHERE deleted_at IS NULL;
@@ -0,0 +1,7 @@
+-- Parent company of a subsidiary; NULL for companies at the top of their group
+ALTER TABLE companies ADD COLUMN parent_id UUID REFERENCES companies(id) ON DELETE SET NULL;
+
+ALTER TABLE companies ADD CONSTRAINT companies_parent_not_self CHECK (parent_id <> id);
+
+-- Subsidiaries of a company, walked by the hierarchy queries
+CREATE INDEX idx_companies_parent_id ON companies (parent_id) WHERE parent_id IS NOT NULL;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS company_identifiers;
+DROP TYPE IF EXISTS company_identifier_scheme;
+
+DROP TABLE IF EXISTS company_contacts;
+
+DROP TABLE IF EXISTS company_addresses;
+DROP TYPE IF EXISTS company_address_kind;
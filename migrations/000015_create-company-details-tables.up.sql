@@ -0,0 +1,60 @@
+-- Addresses, contact people and registration identifiers of companies.
+-- Rows follow their company when it is purged. Identifiers are immutable: changing one takes removing it
+-- and adding the new value.
+CREATE TYPE company_address_kind AS ENUM ('registered', 'operating');
+
+CREATE TABLE IF NOT EXISTS company_addresses (
+    id UUID PRIMARY KEY,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    kind company_address_kind NOT NULL,
+    line1 VARCHAR(200) NOT NULL,
+    line2 VARCHAR(200),
+    city VARCHAR(100) NOT NULL,
+    postal_code VARCHAR(20),
+    region VARCHAR(100),
+    country CHAR(2) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_company_addresses_company_id ON company_addresses (company_id);
+
+-- A company has at most one registered address, and any number of operating addresses
+CREATE UNIQUE INDEX idx_company_addresses_registered ON company_addresses (company_id) WHERE kind = 'registered';
+
+CREATE TABLE IF NOT EXISTS company_contacts (
+    id UUID PRIMARY KEY,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    role VARCHAR(100),
+    email VARCHAR(255),
+    phone VARCHAR(50),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_company_contacts_company_id ON company_contacts (company_id);
+
+CREATE TYPE company_identifier_scheme AS ENUM ('vat', 'lei');
+
+CREATE TABLE IF NOT EXISTS company_identifiers (
+    id UUID PRIMARY KEY,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    scheme company_identifier_scheme NOT NULL,
+    value VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- An identifier designates a single legal entity
+    UNIQUE (scheme, value)
+);
+
+CREATE INDEX idx_company_identifiers_company_id ON company_identifiers (company_id);
+
+CREATE TRIGGER update_company_addresses_updated_at_trigger
+BEFORE UPDATE ON company_addresses
+FOR EACH ROW
+EXECUTE FUNCTION update_company_updated_at();
+
+CREATE TRIGGER update_company_contacts_updated_at_trigger
+BEFORE UPDATE ON company_contacts
+FOR EACH ROW
+EXECUTE FUNCTION update_company_updated_at();
@@ -0,0 +1,7 @@
+-- Fails while companies use types added since, as the enum only has the original four.
+CREATE TYPE company_type AS ENUM ('Corporations', 'NonProfit', 'Cooperative', 'Sole Proprietorship');
+
+ALTER TABLE companies DROP CONSTRAINT IF EXISTS fk_companies_type;
+ALTER TABLE companies ALTER COLUMN type TYPE company_type USING type::company_type;
+
+DROP TABLE IF EXISTS company_types;
@@ -0,0 +1,28 @@
+-- Company types move from the company_type enum to a reference table managed at runtime.
+CREATE TABLE IF NOT EXISTS company_types (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Case-insensitive uniqueness, so types can't differ by case alone
+CREATE UNIQUE INDEX idx_company_types_name_lower ON company_types (LOWER(name));
+
+INSERT INTO company_types (name) VALUES
+    ('Corporations'),
+    ('NonProfit'),
+    ('Cooperative'),
+    ('Sole Proprietorship');
+
+ALTER TABLE companies ALTER COLUMN type TYPE VARCHAR(50) USING type::text;
+
+-- Types in use, including by soft-deleted companies, can't be removed
+ALTER TABLE companies ADD CONSTRAINT fk_companies_type FOREIGN KEY (type) REFERENCES company_types (name);
+
+DROP TYPE company_type;
+
+CREATE TRIGGER update_company_types_updated_at_trigger
+BEFORE UPDATE ON company_types
+FOR EACH ROW
+EXECUTE FUNCTION update_company_updated_at();
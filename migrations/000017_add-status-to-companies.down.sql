@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_companies_status;
+
+ALTER TABLE companies DROP COLUMN IF EXISTS status;
+
+DROP TYPE IF EXISTS company_status;
@@ -0,0 +1,16 @@
+-- Lifecycle status of a company, changed through the transitions enforced by the application
+CREATE TYPE company_status AS ENUM ('draft', 'pending_registration', 'registered', 'suspended', 'dissolved');
+
+ALTER TABLE companies ADD COLUMN status company_status NOT NULL DEFAULT 'draft';
+
+-- Registered companies start out registered. Adding a column isn't a change of the companies,
+-- so the backfill neither bumps their version nor touches updated_at.
+ALTER TABLE companies DISABLE TRIGGER increment_company_version_trigger;
+ALTER TABLE companies DISABLE TRIGGER update_company_updated_at_trigger;
+
+UPDATE companies SET status = 'registered' WHERE registered;
+
+ALTER TABLE companies ENABLE TRIGGER increment_company_version_trigger;
+ALTER TABLE companies ENABLE TRIGGER update_company_updated_at_trigger;
+
+CREATE INDEX idx_companies_status ON companies (status);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS record_company_version_trigger ON companies;
+DROP FUNCTION IF EXISTS record_company_version();
+
+DROP TABLE IF EXISTS company_versions;
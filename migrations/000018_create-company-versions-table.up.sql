@@ -0,0 +1,47 @@
+-- State of a company as of each of its versions, for answering what a company looked like at a point in time.
+-- Rows outlive their company, like its events: purging a company keeps its history.
+CREATE TABLE IF NOT EXISTS company_versions (
+    company_id UUID NOT NULL,
+    version INTEGER NOT NULL,
+    name VARCHAR(15) NOT NULL,
+    description TEXT,
+    amount_of_employees INTEGER NOT NULL,
+    registered BOOLEAN NOT NULL,
+    status company_status NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    owner_id UUID,
+    parent_id UUID,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    deleted_at TIMESTAMPTZ,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (company_id, version)
+);
+
+-- Point in time lookups pick the latest version of a company recorded at or before a timestamp
+CREATE INDEX idx_company_versions_recorded_at ON company_versions (company_id, recorded_at);
+
+-- Every insert and update of a company, whichever code path issues it, is recorded. Updates with
+-- increment_company_version_trigger disabled must disable this trigger too, as they keep the version.
+CREATE OR REPLACE FUNCTION record_company_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO company_versions (company_id, version, name, description, amount_of_employees, registered,
+        status, type, owner_id, parent_id, created_at, updated_at, deleted_at)
+    VALUES (NEW.id, NEW.version, NEW.name, NEW.description, NEW.amount_of_employees, NEW.registered,
+        NEW.status, NEW.type, NEW.owner_id, NEW.parent_id, NEW.created_at, NEW.updated_at, NEW.deleted_at);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_company_version_trigger
+AFTER INSERT OR UPDATE ON companies
+FOR EACH ROW
+EXECUTE FUNCTION record_company_version();
+
+-- Earlier versions of existing companies weren't kept; their current version is recorded as of its last update
+INSERT INTO company_versions (company_id, version, name, description, amount_of_employees, registered,
+    status, type, owner_id, parent_id, created_at, updated_at, deleted_at, recorded_at)
+SELECT id, version, name, description, amount_of_employees, registered,
+    status, type, owner_id, parent_id, created_at, updated_at, deleted_at, updated_at
+FROM companies;
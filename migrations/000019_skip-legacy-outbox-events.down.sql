@@ -0,0 +1,3 @@
+UPDATE events
+SET published_at = NULL, last_error = NULL
+WHERE last_error = 'skipped: stored before the CloudEvents envelope';
@@ -0,0 +1,5 @@
+-- Events stored before the CloudEvents envelope carry the bare company as their data. Ones still
+-- waiting in the outbox, e.g. because the relay was disabled, are not delivered as CloudEvents.
+UPDATE events
+SET published_at = created_at, last_error = 'skipped: stored before the CloudEvents envelope'
+WHERE published_at IS NULL AND NOT data ? 'specversion';
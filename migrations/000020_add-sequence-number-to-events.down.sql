@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_events_unpublished_aggregate;
+
+ALTER TABLE events DROP COLUMN IF EXISTS sequence_number;
@@ -0,0 +1,19 @@
+-- Insertion order of the events, which orders the events of one aggregate even when their created_at ties
+CREATE SEQUENCE events_sequence_number_seq;
+
+ALTER TABLE events ADD COLUMN sequence_number BIGINT;
+
+UPDATE events SET sequence_number = ordered.n
+FROM (SELECT id, row_number() OVER (ORDER BY created_at, id) AS n FROM events) ordered
+WHERE events.id = ordered.id;
+
+SELECT setval('events_sequence_number_seq', COALESCE((SELECT MAX(sequence_number) FROM events), 0) + 1, false);
+
+ALTER TABLE events
+    ALTER COLUMN sequence_number SET DEFAULT nextval('events_sequence_number_seq'),
+    ALTER COLUMN sequence_number SET NOT NULL;
+
+ALTER SEQUENCE events_sequence_number_seq OWNED BY events.sequence_number;
+
+-- Index for the outbox relay finding the oldest unpublished event of an aggregate
+CREATE INDEX idx_events_unpublished_aggregate ON events (aggregate_id, sequence_number) WHERE published_at IS NULL;